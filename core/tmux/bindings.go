@@ -0,0 +1,86 @@
+package tmux
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+type binding struct {
+	option   string
+	alias    string
+	mode     string
+	fallback string
+	popup    bool
+}
+
+var bindings = []*binding{
+	{option: "@teminel_install_key", alias: "@tpm-install", mode: "-install", fallback: "I"},
+	{option: "@teminel_update_key", alias: "@tpm-update", mode: "-update", fallback: "U"},
+	{option: "@teminel_clean_key", alias: "@tpm-clean", mode: "-clean", fallback: "M-u"},
+	{option: "@teminel_ui_key", mode: "-ui", fallback: "T", popup: true},
+}
+
+func findBinding(option string) *binding {
+	for _, binding := range bindings {
+		if binding.option == option || binding.alias == option {
+			return binding
+		}
+	}
+	return nil
+}
+
+func (tmux *Config) Bind() error {
+	if len(tmux.Command) == 0 {
+		return nil
+	}
+	for _, binding := range bindings {
+		key, found := tmux.keys[binding.mode]
+		if !found {
+			key = binding.fallback
+		}
+		if len(key) == 0 || key == "none" {
+			continue
+		}
+		fmt.Println("Binding key:", key, "to teminel", binding.mode)
+		if _, err := tmux.Server.Tmux(append([]string{"bind-key", key}, tmux.bindCommand(binding)...)...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (tmux *Config) bindCommand(binding *binding) []string {
+	command := append(append([]string{}, tmux.Command...), binding.mode)
+	if binding.popup {
+		return []string{"display-popup", "-E", "-w", "80%", "-h", "80%", shellJoin(command)}
+	}
+	if tmux.popup {
+		script := shellJoin(command) + `; printf '\nPress enter to close'; read _`
+		return []string{"display-popup", "-E", script}
+	}
+	return []string{"run-shell", "-b", shellJoin(append(command, "-notify"))}
+}
+
+func (tmux *Config) notify(format string, args ...any) {
+	if !tmux.Notify {
+		return
+	}
+	if _, err := tmux.Server.Tmux("display-message", "teminel: "+fmt.Sprintf(format, args...)); err != nil {
+		fmt.Println("Unable to notify tmux:", err)
+	}
+}
+
+var shellSafeMatcher = regexp.MustCompile(`^[\w@%+=:,./-]+$`)
+
+func shellJoin(args []string) string {
+	quoted := make([]string, len(args))
+	for index, arg := range args {
+		if shellSafeMatcher.MatchString(arg) {
+			quoted[index] = arg
+			continue
+		}
+		quoted[index] = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+	}
+	return strings.Join(quoted, " ")
+}
@@ -0,0 +1,382 @@
+package tmux
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const (
+	bundleManifestName = "manifest.json"
+	bundlePluginsDir   = "plugins"
+)
+
+var bundleDirectoryMatcher = regexp.MustCompile(`^[\w.-]+$`)
+
+type bundleManifest struct {
+	Lock      *Lock             `json:"lock"`
+	Checksums map[string]string `json:"checksums"`
+}
+
+func (tmux *Config) Export(bundlePath string) error {
+	lock, err := ReadLock(tmux.lockPath)
+	if err != nil {
+		return err
+	}
+	manifest := &bundleManifest{
+		Lock:      &Lock{},
+		Checksums: make(map[string]string),
+	}
+	var plugins []*Plugin
+	for _, plugin := range tmux.active() {
+		if len(plugin.local) != 0 {
+			fmt.Println("Skipping local plugin in bundle:", plugin.name)
+			continue
+		}
+		plugins = append(plugins, plugin)
+		entry := lock.Find(plugin.host, plugin.name)
+		if entry == nil {
+			return fmt.Errorf("Plugin %v is not part of the lockfile, run install first", plugin.name)
+		}
+		manifest.Lock.Plugins = append(manifest.Lock.Plugins, entry)
+		if err := tmux.walkPlugin(plugin, func(name string, file string, info fs.FileInfo) error {
+			if info.IsDir() {
+				return nil
+			}
+			checksum, err := checksumFile(file, info)
+			manifest.Checksums[name] = checksum
+			return err
+		}); err != nil {
+			return err
+		}
+	}
+	file, err := os.Create(bundlePath)
+	if err != nil {
+		return err
+	}
+	gzipWriter := gzip.NewWriter(file)
+	writer := tar.NewWriter(gzipWriter)
+	err = tmux.writeBundle(writer, manifest, plugins)
+	for _, closer := range []io.Closer{writer, gzipWriter, file} {
+		if closeErr := closer.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Println("Exported", len(plugins), "plugins to:", bundlePath)
+	return nil
+}
+
+func (tmux *Config) writeBundle(writer *tar.Writer, manifest *bundleManifest, plugins []*Plugin) error {
+	if err := writeManifest(writer, manifest); err != nil {
+		return err
+	}
+	for _, plugin := range plugins {
+		fmt.Println("Exporting plugin:", plugin.name)
+		if err := tmux.walkPlugin(plugin, func(name string, file string, info fs.FileInfo) error {
+			return writeBundleEntry(writer, name, file, info)
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (tmux *Config) Import(bundlePath string) error {
+	file, err := os.Open(bundlePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	gzipReader, err := gzip.NewReader(file)
+	if err != nil {
+		return err
+	}
+	defer gzipReader.Close()
+	reader := tar.NewReader(gzipReader)
+	manifest, err := readManifest(reader)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(tmux.path), os.FileMode(0755)); err != nil {
+		return err
+	}
+	staging, err := os.MkdirTemp(filepath.Dir(tmux.path), ".teminel-import-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(staging)
+	if err := extractBundle(reader, manifest, staging); err != nil {
+		return err
+	}
+	for _, entry := range manifest.Lock.Plugins {
+		directory := filepath.Base(entry.Name)
+		if !bundleDirectoryMatcher.MatchString(directory) || directory == "." || directory == ".." {
+			return fmt.Errorf("Bundle contains invalid plugin name: %q", entry.Name)
+		}
+		staged := filepath.Join(staging, bundlePluginsDir, directory)
+		if info, err := os.Lstat(staged); err != nil || !info.IsDir() {
+			return fmt.Errorf("Bundle does not contain plugin: %v", entry.Name)
+		}
+	}
+	if err := os.MkdirAll(tmux.path, os.FileMode(0755)); err != nil {
+		return err
+	}
+	for _, entry := range manifest.Lock.Plugins {
+		directory := filepath.Base(entry.Name)
+		staged := filepath.Join(staging, bundlePluginsDir, directory)
+		target := filepath.Join(tmux.path, directory)
+		fmt.Println("Importing plugin:", entry.Name, "to:", target)
+		if err := os.RemoveAll(target); err != nil {
+			return err
+		}
+		if err := os.Rename(staged, target); err != nil {
+			return err
+		}
+	}
+	return tmux.mergeLock(manifest.Lock)
+}
+
+func (tmux *Config) mergeLock(imported *Lock) error {
+	lock, err := ReadLock(tmux.lockPath)
+	if err != nil {
+		return err
+	}
+	for _, entry := range imported.Plugins {
+		if existing := lock.Find(entry.Host, entry.Name); existing != nil {
+			*existing = *entry
+		} else {
+			lock.Plugins = append(lock.Plugins, entry)
+		}
+	}
+	for _, plugin := range tmux.active() {
+		if len(plugin.local) == 0 && imported.Find(plugin.host, plugin.name) == nil {
+			fmt.Println("Warning: Plugin is not part of the bundle:", plugin.name)
+		}
+	}
+	fmt.Println("Writing lockfile:", tmux.lockPath)
+	return lock.Write(tmux.lockPath)
+}
+
+func (tmux *Config) walkPlugin(plugin *Plugin, visit func(string, string, fs.FileInfo) error) error {
+	root := filepath.Join(tmux.path, plugin.directory())
+	return filepath.Walk(root, func(file string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relative, err := filepath.Rel(root, file)
+		if err != nil {
+			return err
+		}
+		name := path.Join(bundlePluginsDir, plugin.directory(), filepath.ToSlash(relative))
+		return visit(name, file, info)
+	})
+}
+
+func checksumFile(file string, info fs.FileInfo) (string, error) {
+	hash := sha256.New()
+	if info.Mode()&fs.ModeSymlink != 0 {
+		target, err := os.Readlink(file)
+		if err != nil {
+			return "", err
+		}
+		hash.Write([]byte(target))
+	} else {
+		content, err := os.Open(file)
+		if err != nil {
+			return "", err
+		}
+		defer content.Close()
+		if _, err := io.Copy(hash, content); err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func writeManifest(writer *tar.Writer, manifest *bundleManifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	header := &tar.Header{
+		Name:     bundleManifestName,
+		Mode:     0644,
+		Size:     int64(len(data)),
+		Typeflag: tar.TypeReg,
+	}
+	if err := writer.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err = writer.Write(data)
+	return err
+}
+
+func writeBundleEntry(writer *tar.Writer, name string, file string, info fs.FileInfo) error {
+	link := ""
+	if info.Mode()&fs.ModeSymlink != 0 {
+		target, err := os.Readlink(file)
+		if err != nil {
+			return err
+		}
+		link = target
+	}
+	header, err := tar.FileInfoHeader(info, link)
+	if err != nil {
+		return err
+	}
+	header.Name = name
+	if err := writer.WriteHeader(header); err != nil {
+		return err
+	}
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+	content, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+	_, err = io.Copy(writer, content)
+	return err
+}
+
+func readManifest(reader *tar.Reader) (*bundleManifest, error) {
+	header, err := reader.Next()
+	if err != nil {
+		return nil, err
+	}
+	if header.Name != bundleManifestName {
+		return nil, fmt.Errorf("Bundle does not start with a manifest")
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	manifest := &bundleManifest{}
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, err
+	}
+	if manifest.Lock == nil {
+		return nil, fmt.Errorf("Bundle manifest does not contain a lock")
+	}
+	return manifest, nil
+}
+
+func extractBundle(reader *tar.Reader, manifest *bundleManifest, staging string) error {
+	verified := 0
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if !filepath.IsLocal(header.Name) || !strings.HasPrefix(header.Name, bundlePluginsDir+"/") {
+			return fmt.Errorf("Bundle contains invalid path: %v", header.Name)
+		}
+		target := filepath.Join(staging, filepath.FromSlash(header.Name))
+		if err := checkBundlePath(staging, target); err != nil {
+			return err
+		}
+		if header.Typeflag == tar.TypeDir {
+			if err := os.MkdirAll(target, os.FileMode(header.Mode)|0700); err != nil {
+				return err
+			}
+			continue
+		}
+		expected, found := manifest.Checksums[header.Name]
+		if !found {
+			return fmt.Errorf("Bundle contains unlisted file: %v", header.Name)
+		}
+		directory, _, _ := strings.Cut(strings.TrimPrefix(header.Name, bundlePluginsDir+"/"), "/")
+		root := filepath.Join(staging, bundlePluginsDir, directory)
+		checksum, err := extractBundleEntry(reader, header, root, target)
+		if err != nil {
+			return err
+		}
+		if checksum != expected {
+			return fmt.Errorf("Checksum mismatch for %v: expected %v, got %v", header.Name, expected, checksum)
+		}
+		verified++
+	}
+	if verified != len(manifest.Checksums) {
+		return fmt.Errorf("Bundle is incomplete: %v of %v files found", verified, len(manifest.Checksums))
+	}
+	fmt.Println("Verified checksums of", verified, "files")
+	return nil
+}
+
+func extractBundleEntry(reader *tar.Reader, header *tar.Header, root string, target string) (string, error) {
+	if err := os.MkdirAll(filepath.Dir(target), os.FileMode(0755)); err != nil {
+		return "", err
+	}
+	hash := sha256.New()
+	switch header.Typeflag {
+	case tar.TypeSymlink:
+		if err := checkBundleLink(root, filepath.Dir(target), header.Name, header.Linkname); err != nil {
+			return "", err
+		}
+		hash.Write([]byte(header.Linkname))
+		if err := os.Symlink(header.Linkname, target); err != nil {
+			return "", err
+		}
+	case tar.TypeReg:
+		file, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode))
+		if err != nil {
+			return "", err
+		}
+		defer file.Close()
+		if _, err := io.Copy(io.MultiWriter(file, hash), reader); err != nil {
+			return "", err
+		}
+	default:
+		return "", fmt.Errorf("Bundle contains unsupported entry: %v", header.Name)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func checkBundlePath(staging string, target string) error {
+	relative, err := filepath.Rel(staging, target)
+	if err != nil {
+		return err
+	}
+	current := staging
+	for _, part := range strings.Split(relative, string(filepath.Separator)) {
+		current = filepath.Join(current, part)
+		info, err := os.Lstat(current)
+		if os.IsNotExist(err) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if info.Mode()&fs.ModeSymlink != 0 {
+			return fmt.Errorf("Bundle path goes through a symlink: %v", filepath.ToSlash(relative))
+		}
+	}
+	return nil
+}
+
+func checkBundleLink(root string, directory string, name string, link string) error {
+	if filepath.IsAbs(link) {
+		return fmt.Errorf("Bundle contains absolute symlink: %v -> %v", name, link)
+	}
+	relative, err := filepath.Rel(root, filepath.Join(directory, link))
+	if err != nil || !filepath.IsLocal(relative) {
+		return fmt.Errorf("Bundle contains symlink leaving its plugin: %v -> %v", name, link)
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+package tmux
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type bundleEntry struct {
+	name    string
+	content string
+	link    string
+	dir     bool
+}
+
+func TestImportBundle(t *testing.T) {
+	tests := []struct {
+		name    string
+		plugins []string
+		entries []bundleEntry
+		fails   bool
+	}{
+		{
+			name:    "plugin with files and a local symlink",
+			plugins: []string{"owner/alpha"},
+			entries: []bundleEntry{
+				{name: "plugins/alpha", dir: true},
+				{name: "plugins/alpha/alpha.tmux", content: "#!/bin/sh\n"},
+				{name: "plugins/alpha/scripts/run.sh", content: "echo run\n"},
+				{name: "plugins/alpha/run.sh", link: "scripts/run.sh"},
+			},
+		},
+		{
+			name:    "absolute symlink",
+			plugins: []string{"owner/alpha"},
+			entries: []bundleEntry{{name: "plugins/alpha/escape", link: "/etc"}},
+			fails:   true,
+		},
+		{
+			name:    "symlink leaving the staging directory",
+			plugins: []string{"owner/alpha"},
+			entries: []bundleEntry{{name: "plugins/alpha/escape", link: "../../../outside"}},
+			fails:   true,
+		},
+		{
+			name:    "symlink into another plugin",
+			plugins: []string{"owner/alpha", "owner/beta"},
+			entries: []bundleEntry{
+				{name: "plugins/beta/beta.tmux", content: "#!/bin/sh\n"},
+				{name: "plugins/alpha/beta", link: "../beta"},
+			},
+			fails: true,
+		},
+		{
+			name:    "file written through a symlink",
+			plugins: []string{"owner/alpha"},
+			entries: []bundleEntry{
+				{name: "plugins/alpha/self", link: "."},
+				{name: "plugins/alpha/self/alpha.tmux", content: "#!/bin/sh\n"},
+			},
+			fails: true,
+		},
+		{
+			name:    "path outside of the plugins directory",
+			plugins: []string{"owner/alpha"},
+			entries: []bundleEntry{{name: "plugins/../evil.sh", content: "echo evil\n"}},
+			fails:   true,
+		},
+		{
+			name:    "file next to the plugins directory",
+			plugins: []string{"owner/alpha"},
+			entries: []bundleEntry{{name: "evil.sh", content: "echo evil\n"}},
+			fails:   true,
+		},
+		{
+			name:    "plugin name resolving to the parent directory",
+			plugins: []string{"owner/.."},
+			entries: []bundleEntry{{name: "plugins/alpha/alpha.tmux", content: "#!/bin/sh\n"}},
+			fails:   true,
+		},
+		{
+			name:    "plugin missing from the bundle",
+			plugins: []string{"owner/alpha", "owner/beta"},
+			entries: []bundleEntry{{name: "plugins/alpha/alpha.tmux", content: "#!/bin/sh\n"}},
+			fails:   true,
+		},
+	}
+	for _, test := range tests {
+		directory := t.TempDir()
+		bundlePath := filepath.Join(directory, "bundle.tar.gz")
+		writeTestBundle(t, bundlePath, test.plugins, test.entries, nil)
+		tmux := &Config{
+			path:     filepath.Join(directory, "home", "plugins"),
+			lockPath: filepath.Join(directory, "home", "tmux.conf.lock"),
+		}
+		err := tmux.Import(bundlePath)
+		if test.fails {
+			if err == nil {
+				t.Errorf("%v: import succeeded, expected an error", test.name)
+			}
+			if matches, _ := filepath.Glob(filepath.Join(directory, "home", "plugins", "*")); len(matches) != 0 {
+				t.Errorf("%v: plugins imported despite the error: %v", test.name, matches)
+			}
+			if _, err := os.Lstat(filepath.Join(directory, "outside")); err == nil {
+				t.Errorf("%v: file created outside of the plugin path", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: import failed: %v", test.name, err)
+			continue
+		}
+		for _, entry := range test.entries {
+			if entry.dir || len(entry.link) != 0 {
+				continue
+			}
+			target := filepath.Join(tmux.path, filepath.FromSlash(entry.name[len("plugins/"):]))
+			if content, err := os.ReadFile(target); err != nil || string(content) != entry.content {
+				t.Errorf("%v: imported %v = %q, %v, expected %q", test.name, target, content, err, entry.content)
+			}
+		}
+	}
+}
+
+func TestImportBundleChecksums(t *testing.T) {
+	entries := []bundleEntry{{name: "plugins/alpha/alpha.tmux", content: "#!/bin/sh\n"}}
+	tests := []struct {
+		name      string
+		checksums map[string]string
+	}{
+		{name: "checksum mismatch", checksums: map[string]string{"plugins/alpha/alpha.tmux": checksum("changed")}},
+		{name: "unlisted file", checksums: map[string]string{}},
+		{name: "incomplete bundle", checksums: map[string]string{
+			"plugins/alpha/alpha.tmux": checksum("#!/bin/sh\n"),
+			"plugins/alpha/other.sh":   checksum("echo other\n"),
+		}},
+	}
+	for _, test := range tests {
+		directory := t.TempDir()
+		bundlePath := filepath.Join(directory, "bundle.tar.gz")
+		writeTestBundle(t, bundlePath, []string{"owner/alpha"}, entries, test.checksums)
+		tmux := &Config{
+			path:     filepath.Join(directory, "plugins"),
+			lockPath: filepath.Join(directory, "tmux.conf.lock"),
+		}
+		if err := tmux.Import(bundlePath); err == nil {
+			t.Errorf("%v: import succeeded, expected an error", test.name)
+		}
+	}
+}
+
+func writeTestBundle(t *testing.T, bundlePath string, plugins []string, entries []bundleEntry, checksums map[string]string) {
+	t.Helper()
+	manifest := &bundleManifest{Lock: &Lock{}, Checksums: checksums}
+	for _, name := range plugins {
+		manifest.Lock.Plugins = append(manifest.Lock.Plugins, &LockEntry{Host: "github.com", Name: name})
+	}
+	if manifest.Checksums == nil {
+		manifest.Checksums = make(map[string]string)
+		for _, entry := range entries {
+			if len(entry.link) != 0 {
+				manifest.Checksums[entry.name] = checksum(entry.link)
+			} else if !entry.dir {
+				manifest.Checksums[entry.name] = checksum(entry.content)
+			}
+		}
+	}
+	file, err := os.Create(bundlePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	gzipWriter := gzip.NewWriter(file)
+	defer gzipWriter.Close()
+	writer := tar.NewWriter(gzipWriter)
+	defer writer.Close()
+	if err := writeManifest(writer, manifest); err != nil {
+		t.Fatal(err)
+	}
+	for _, entry := range entries {
+		header := &tar.Header{Name: entry.name, Mode: 0644, Typeflag: tar.TypeReg, Size: int64(len(entry.content))}
+		if entry.dir {
+			header = &tar.Header{Name: entry.name, Mode: 0755, Typeflag: tar.TypeDir}
+		} else if len(entry.link) != 0 {
+			header = &tar.Header{Name: entry.name, Mode: 0777, Typeflag: tar.TypeSymlink, Linkname: entry.link}
+		}
+		if err := writer.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := writer.Write([]byte(entry.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func checksum(content string) string {
+	hash := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(hash[:])
+}
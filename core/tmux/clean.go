@@ -0,0 +1,52 @@
+package tmux
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+func (tmux *Config) Orphans() ([]string, error) {
+	entries, err := os.ReadDir(tmux.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	declared := make(map[string]bool, len(tmux.plugins))
+	for _, plugin := range tmux.plugins {
+		declared[plugin.directory()] = true
+	}
+	var orphans []string
+	for _, entry := range entries {
+		isPlugin := entry.IsDir() || entry.Type()&fs.ModeSymlink != 0
+		if isPlugin && !declared[entry.Name()] {
+			orphans = append(orphans, filepath.Join(tmux.path, entry.Name()))
+		}
+	}
+	return orphans, nil
+}
+
+func (tmux *Config) Clean(dryRun bool) error {
+	orphans, err := tmux.Orphans()
+	if err != nil {
+		return err
+	}
+	if len(orphans) == 0 {
+		fmt.Println("No orphaned plugins found in:", tmux.path)
+		return nil
+	}
+	for _, orphan := range orphans {
+		if dryRun {
+			fmt.Println("Would remove orphaned plugin:", orphan)
+			continue
+		}
+		fmt.Println("Removing orphaned plugin:", orphan)
+		tmux.notify("removing %v", filepath.Base(orphan))
+		if err := os.RemoveAll(orphan); err != nil {
+			return err
+		}
+	}
+	return nil
+}
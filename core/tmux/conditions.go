@@ -0,0 +1,183 @@
+package tmux
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+
+	"source.cyberpi.de/go/teminel/exec"
+)
+
+type condition struct {
+	kind  string
+	value string
+}
+
+var (
+	versionMatcher    = regexp.MustCompile(`(\d+)(\.(\d+))?([a-z]?)`)
+	constraintMatcher = regexp.MustCompile(`^(>=|<=|>|<|=)?(.+)$`)
+	operatorMatcher   = regexp.MustCompile(`(>=|<=|>|<|=)\s+`)
+	tmuxVersion       = sync.OnceValues(readTmuxVersion)
+)
+
+func (tmux *Config) evaluate() {
+	for _, plugin := range tmux.plugins {
+		plugin.skip = tmux.skipReason(plugin)
+		if len(plugin.skip) != 0 {
+			fmt.Printf("Skipping plugin: %v: %v\n", plugin.name, plugin.skip)
+		}
+	}
+}
+
+func (tmux *Config) skipReason(plugin *Plugin) string {
+	if tmux.disabled[plugin.name] {
+		return disabledReason
+	}
+	if reason := tmux.profileReason(plugin); len(reason) != 0 {
+		return reason
+	}
+	for _, condition := range plugin.conditions {
+		if reason := condition.check(); len(reason) != 0 {
+			return reason
+		}
+	}
+	return ""
+}
+
+func (tmux *Config) active() []*Plugin {
+	var plugins []*Plugin
+	for _, plugin := range tmux.plugins {
+		if len(plugin.skip) == 0 {
+			plugins = append(plugins, plugin)
+		}
+	}
+	return plugins
+}
+
+func (condition *condition) check() string {
+	switch condition.kind {
+	case "tmux":
+		version, err := tmuxVersion()
+		if err != nil {
+			return fmt.Sprintf("tmux version unknown: %v", err)
+		}
+		matches, err := matchesVersion(version, condition.value)
+		if err != nil {
+			return err.Error()
+		}
+		if !matches {
+			return fmt.Sprintf("tmux %v does not match %v", version, condition.value)
+		}
+	case "host":
+		hostname, err := os.Hostname()
+		if err != nil {
+			return fmt.Sprintf("hostname unknown: %v", err)
+		}
+		if !matchesAny(hostname, condition.value) {
+			return fmt.Sprintf("hostname %v does not match %v", hostname, condition.value)
+		}
+	case "os":
+		if !matchesAny(runtime.GOOS, condition.value) {
+			return fmt.Sprintf("os %v does not match %v", runtime.GOOS, condition.value)
+		}
+	case "env":
+		name, negated := strings.CutPrefix(condition.value, "!")
+		if _, found := os.LookupEnv(name); found == negated {
+			if negated {
+				return fmt.Sprintf("environment variable %v is set", name)
+			}
+			return fmt.Sprintf("environment variable %v is not set", name)
+		}
+	}
+	return ""
+}
+
+func matchesAny(value string, patterns string) bool {
+	for _, pattern := range strings.Split(patterns, ",") {
+		if matched, _ := path.Match(strings.TrimSpace(pattern), value); matched {
+			return true
+		}
+	}
+	return false
+}
+
+func readTmuxVersion() (string, error) {
+	output, err := exec.Tmux("-V")
+	if err != nil {
+		return "", err
+	}
+	version := versionMatcher.FindString(output)
+	if len(version) == 0 {
+		return "", fmt.Errorf("Unable to parse tmux version: %v", strings.TrimSpace(output))
+	}
+	return version, nil
+}
+
+func matchesVersion(version string, constraints string) (bool, error) {
+	current, err := parseVersion(version)
+	if err != nil {
+		return false, err
+	}
+	constraints = operatorMatcher.ReplaceAllString(constraints, "$1")
+	for _, constraint := range strings.FieldsFunc(constraints, func(char rune) bool {
+		return char == ' ' || char == ','
+	}) {
+		match := constraintMatcher.FindStringSubmatch(constraint)
+		expected, err := parseVersion(match[2])
+		if err != nil {
+			return false, err
+		}
+		comparison := compareVersions(current, expected)
+		switch match[1] {
+		case ">=":
+			if comparison < 0 {
+				return false, nil
+			}
+		case "<=":
+			if comparison > 0 {
+				return false, nil
+			}
+		case ">":
+			if comparison <= 0 {
+				return false, nil
+			}
+		case "<":
+			if comparison >= 0 {
+				return false, nil
+			}
+		default:
+			if comparison != 0 {
+				return false, nil
+			}
+		}
+	}
+	return true, nil
+}
+
+func parseVersion(version string) ([3]int, error) {
+	match := versionMatcher.FindStringSubmatch(version)
+	if match == nil {
+		return [3]int{}, fmt.Errorf("Invalid tmux version: %v", version)
+	}
+	major, _ := strconv.Atoi(match[1])
+	minor, _ := strconv.Atoi(match[3])
+	patch := 0
+	if len(match[4]) != 0 {
+		patch = int(match[4][0]-'a') + 1
+	}
+	return [3]int{major, minor, patch}, nil
+}
+
+func compareVersions(left [3]int, right [3]int) int {
+	for index := range left {
+		if left[index] != right[index] {
+			return left[index] - right[index]
+		}
+	}
+	return 0
+}
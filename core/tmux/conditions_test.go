@@ -0,0 +1,50 @@
+package tmux
+
+import "testing"
+
+func TestMatchesVersion(t *testing.T) {
+	tests := []struct {
+		version     string
+		constraints string
+		expected    bool
+		fails       bool
+	}{
+		{version: "3.3a", constraints: ">=3.2", expected: true},
+		{version: "3.2", constraints: ">=3.2", expected: true},
+		{version: "3.2", constraints: ">3.2", expected: false},
+		{version: "3.2a", constraints: ">3.2", expected: true},
+		{version: "3.3", constraints: "<3.3a", expected: true},
+		{version: "3.3a", constraints: "<=3.3", expected: false},
+		{version: "3.3a", constraints: "3.3a", expected: true},
+		{version: "3.3a", constraints: "=3.3", expected: false},
+		{version: "3", constraints: "=3.0", expected: true},
+		{version: "next-3.4", constraints: ">=3.3", expected: true},
+		{version: "openbsd-7.4", constraints: ">=3.3", expected: true},
+		{version: "3.1c", constraints: ">=2.9, <3.2", expected: true},
+		{version: "3.2", constraints: ">=2.9,<3.2", expected: false},
+		{version: "2.8", constraints: ">=2.9 <3.2", expected: false},
+		{version: "3.3a", constraints: ">= 3.2", expected: true},
+		{version: "3.1", constraints: ">= 3.2", expected: false},
+		{version: "3.3", constraints: ">= 2.9, < 3.4", expected: true},
+		{version: "3.4", constraints: "> 3.2 <= 3.3a", expected: false},
+		{version: "3.4", constraints: "", expected: true},
+		{version: "master", constraints: ">=3.2", fails: true},
+		{version: "3.4", constraints: ">=next", fails: true},
+	}
+	for _, test := range tests {
+		matches, err := matchesVersion(test.version, test.constraints)
+		if test.fails {
+			if err == nil {
+				t.Errorf("matchesVersion(%q, %q) = %v, expected an error", test.version, test.constraints, matches)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("matchesVersion(%q, %q) failed: %v", test.version, test.constraints, err)
+			continue
+		}
+		if matches != test.expected {
+			t.Errorf("matchesVersion(%q, %q) = %v, expected %v", test.version, test.constraints, matches, test.expected)
+		}
+	}
+}
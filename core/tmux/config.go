@@ -5,7 +5,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"regexp"
+	"strings"
+	"time"
 
 	"source.cyberpi.de/go/teminel/exec"
 	"source.cyberpi.de/go/teminel/load"
@@ -13,90 +14,414 @@ import (
 )
 
 type Config struct {
-	Source  *load.GitSource
-	path    string
-	plugins []*Plugin
+	Source         *load.GitSource
+	Jobs           int
+	Command        []string
+	Notify         bool
+	Timeout        time.Duration
+	HookTimeout    time.Duration
+	Server         *exec.Server
+	Policy         *Policy
+	AllowUntrusted bool
+	Profile        string
+	path           string
+	lockPath       string
+	disabledPath   string
+	disabled       map[string]bool
+	plugins        []*Plugin
+	unparsed       []*Unparsed
+	keys           map[string]string
+	popup          bool
+	timings        []*Timing
+	files          []string
+	patterns       []string
 }
 
 type Plugin struct {
-	host string
-	name string
+	host        string
+	name        string
+	reference   string
+	url         string
+	local       string
+	file        string
+	line        int
+	conditions  []*condition
+	skip        string
+	postInstall string
+	postUpdate  string
+	after       []string
+	requires    []string
+	lazy        []string
+	profiles    []string
 }
 
-var tpmPluginMatcher = regexp.MustCompile(`^set -g @(plugin(_host|_path)?) ["']?([\w/-]+?)["']?$`)
+type reader struct {
+	host     string
+	profiles []string
+	visited  map[string]bool
+	last     *Plugin
+}
+
+const pluginPathVariable = "TMUX_PLUGIN_MANAGER_PATH"
 
 func (tmux *Config) Read(configPath string) error {
-	tmux.path = filepath.Join(filepath.Dir(configPath), "plugins")
-	file, err := os.Open(configPath)
+	path, err := defaultPluginPath(configPath)
+	if err != nil {
+		return err
+	}
+	tmux.path = path
+	tmux.lockPath = configPath + ".lock"
+	tmux.disabledPath = configPath + ".disabled"
+	tmux.keys = make(map[string]string)
+	if tmux.disabled, err = readDisabled(tmux.disabledPath); err != nil {
+		return err
+	}
+	err = tmux.readFile(configPath, &reader{
+		host:    tmux.Source.Archive.Host,
+		visited: make(map[string]bool),
+	})
+	for _, unparsed := range tmux.unparsed {
+		fmt.Printf("Warning: Unable to parse %v:%v: %v: %v\n", unparsed.file, unparsed.line, unparsed.reason, unparsed.text)
+	}
+	if err := tmux.selectProfile(configPath); err != nil {
+		return err
+	}
+	tmux.evaluate()
+	return err
+}
+
+func (tmux *Config) readFile(configPath string, state *reader) error {
+	absolutePath, err := filepath.Abs(configPath)
+	if err != nil {
+		return err
+	}
+	if state.visited[absolutePath] {
+		fmt.Println("Skipping already read config file:", absolutePath)
+		return nil
+	}
+	state.visited[absolutePath] = true
+	tmux.files = append(tmux.files, absolutePath)
+	file, err := os.Open(absolutePath)
 	if err != nil {
 		return err
 	}
 	defer file.Close()
 	scanner := bufio.NewScanner(file)
-	host := tmux.Source.Archive.Host
+	number, start := 0, 0
+	text := ""
 	for scanner.Scan() {
+		number++
+		if len(text) == 0 {
+			start = number
+		}
 		line := scanner.Text()
-		match := tpmPluginMatcher.FindStringSubmatch(line)
-		if match != nil {
-			switch match[1] {
-			case "plugin_host":
-				host = match[3]
-			case "plugin_path":
-				tmux.path = match[3]
-			default:
-				tmux.plugins = append(tmux.plugins, &Plugin{
-					host: host,
-					name: match[3],
-				})
+		if continuesLine(line) {
+			text += strings.TrimSuffix(line, "\\") + " "
+			continue
+		}
+		text += line
+		commands, err := tokenize(text)
+		if err != nil {
+			tmux.report(absolutePath, start, text, err)
+			state.last = nil
+		}
+		for _, command := range commands {
+			if err := tmux.interpret(command, absolutePath, start, state); err != nil {
+				tmux.report(absolutePath, start, text, err)
+			}
+		}
+		text = ""
+	}
+	return scanner.Err()
+}
+
+func (tmux *Config) interpret(command []string, file string, line int, state *reader) error {
+	switch command[0] {
+	case "source", "source-file":
+		paths, quiet := parseSourceFile(command[1:])
+		for _, path := range paths {
+			if err := tmux.sourceFiles(file, path, quiet, state); err != nil {
+				return err
+			}
+		}
+	case "set", "set-option":
+		option, err := parseSetOption(command[1:])
+		if err != nil {
+			if mentionsPlugins(command) {
+				state.last = nil
+				return err
 			}
+			return nil
+		}
+		return tmux.apply(option, file, line, state)
+	case "set-environment", "setenv":
+		variable, err := parseSetOption(command[1:])
+		if err != nil || variable.unset || variable.name != pluginPathVariable {
+			return nil
+		}
+		path, err := utils.ResolvePath(variable.value)
+		if err != nil {
+			return err
+		}
+		tmux.path = path
+	}
+	return nil
+}
+
+func (tmux *Config) apply(option *setOption, file string, line int, state *reader) error {
+	if binding := findBinding(option.name); binding != nil {
+		if !option.unset {
+			tmux.keys[binding.mode] = option.value
+		}
+		return nil
+	}
+	if option.name == "@teminel_popup" {
+		tmux.popup = option.value == "on"
+		return nil
+	}
+	if option.unset && option.name == "@plugin_profile" {
+		state.profiles = nil
+		return nil
+	}
+	if option.unset || !isPluginOption(option.name) {
+		return nil
+	}
+	if option.name == "@plugin" || option.name == "@tpm_plugins" {
+		state.last = nil
+	}
+	if len(option.value) == 0 {
+		return fmt.Errorf("Missing value for %v", option.name)
+	}
+	switch option.name {
+	case "@plugin":
+		return tmux.declare(option.value, file, line, state)
+	case "@tpm_plugins":
+		for _, spec := range strings.Fields(option.value) {
+			if err := tmux.declare(spec, file, line, state); err != nil {
+				return err
+			}
+		}
+	case "@plugin_host":
+		state.host = option.value
+	case "@plugin_profile":
+		state.profiles = strings.Fields(option.value)
+	case "@plugin_path":
+		path, err := utils.ResolvePath(option.value)
+		if err != nil {
+			return err
+		}
+		tmux.path = path
+	case "@plugin_if_tmux", "@plugin_if_host", "@plugin_if_os", "@plugin_if_env":
+		plugin, err := state.current(option)
+		if err != nil {
+			return err
+		}
+		plugin.conditions = append(plugin.conditions, &condition{
+			kind:  strings.TrimPrefix(option.name, "@plugin_if_"),
+			value: option.value,
+		})
+	case "@plugin_post_install":
+		plugin, err := state.current(option)
+		if err != nil {
+			return err
+		}
+		plugin.postInstall = option.value
+	case "@plugin_post_update":
+		plugin, err := state.current(option)
+		if err != nil {
+			return err
+		}
+		plugin.postUpdate = option.value
+	case "@plugin_after":
+		plugin, err := state.current(option)
+		if err != nil {
+			return err
+		}
+		plugin.after = append(plugin.after, strings.Fields(option.value)...)
+	case "@plugin_lazy":
+		plugin, err := state.current(option)
+		if err != nil {
+			return err
 		}
+		plugin.lazy = append(plugin.lazy, strings.Fields(option.value)...)
+	case "@plugin_requires":
+		plugin, err := state.current(option)
+		if err != nil {
+			return err
+		}
+		plugin.requires = append(plugin.requires, strings.Fields(option.value)...)
+	default:
+		return fmt.Errorf("Unknown plugin option %v", option.name)
+	}
+	return nil
+}
+
+func (state *reader) current(option *setOption) (*Plugin, error) {
+	if state.last == nil {
+		return nil, fmt.Errorf("%v has to follow a plugin declaration", option.name)
+	}
+	return state.last, nil
+}
+
+func (tmux *Config) declare(spec string, file string, line int, state *reader) error {
+	plugin, err := parseSpec(spec, state.host, file)
+	if err != nil {
+		return err
 	}
+	plugin.file = file
+	plugin.line = line
+	plugin.profiles = state.profiles
+	tmux.plugins = append(tmux.plugins, plugin)
+	state.last = plugin
 	return nil
 }
 
-func (tmux *Config) Install() error {
-	_, err := os.Stat(tmux.path)
-	if os.IsNotExist(err) {
-		os.Mkdir(tmux.path, os.FileMode(0755))
-	} else if err != nil {
+func (tmux *Config) report(file string, line int, text string, err error) {
+	tmux.unparsed = append(tmux.unparsed, &Unparsed{
+		file:   file,
+		line:   line,
+		text:   strings.TrimSpace(text),
+		reason: err.Error(),
+	})
+}
+
+func mentionsPlugins(command []string) bool {
+	for _, arg := range command {
+		if isPluginOption(arg) {
+			return true
+		}
+	}
+	return false
+}
+
+func isPluginOption(name string) bool {
+	return strings.HasPrefix(name, "@plugin") || name == "@tpm_plugins"
+}
+
+func (tmux *Config) sourceFiles(from string, pattern string, quiet bool, state *reader) error {
+	resolved, err := utils.ResolvePath(pattern)
+	if err != nil {
+		return err
+	}
+	if !filepath.IsAbs(resolved) {
+		resolved = filepath.Join(filepath.Dir(from), resolved)
+	}
+	matches, err := filepath.Glob(resolved)
+	if err != nil {
 		return err
 	}
-	for _, plugin := range tmux.plugins {
-		tmux.Source.Archive.Host = plugin.host
-		tmux.Source.EnsureRepository(plugin.name, tmux.path)
+	tmux.patterns = append(tmux.patterns, resolved)
+	if len(matches) == 0 && !quiet {
+		fmt.Println("Warning: No file found to source:", pattern, "from:", from)
+	}
+	for _, match := range matches {
+		if err := tmux.readFile(match, state); err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
 func (tmux *Config) Load() error {
-	for _, plugin := range tmux.plugins {
-		fmt.Println("Start plugin:", plugin.name)
-		glob := fmt.Sprintf("%v/%v/*.tmux", tmux.path, filepath.Base(plugin.name))
-		toLoad, err := filepath.Glob(glob)
+	if err := tmux.Bind(); err != nil {
+		fmt.Println("Unable to bind teminel keys:", err)
+	}
+	plugins, err := tmux.ordered()
+	if err != nil {
+		return err
+	}
+	var failed []string
+	for _, plugin := range plugins {
+		fmt.Println("Start plugin:", plugin.name, "declared at:", plugin.declaration())
+		err := tmux.requirement(plugin)
+		if err == nil {
+			err = tmux.failedRequirement(plugin, failed)
+		}
+		if err == nil {
+			err = tmux.start(plugin)
+		}
 		if err != nil {
-			return err
+			fmt.Println("Plugin failed to load:", plugin.name, err)
+			failed = append(failed, plugin.name)
 		}
-		for _, item := range toLoad {
-			exec.Shell(item)
+	}
+	if len(failed) != 0 {
+		message := fmt.Sprintf("teminel: plugins failed to load: %v", strings.Join(failed, ", "))
+		if _, err := tmux.Server.Tmux("display-message", message); err != nil {
+			fmt.Println("Unable to report failed plugins:", err)
 		}
+		return fmt.Errorf("%v of %v plugins failed to load", len(failed), len(plugins))
 	}
 	return nil
 }
 
-func SelectConfig() (string, error) {
-	homeDir, err := os.UserHomeDir()
+func (tmux *Config) loadPlugin(plugin *Plugin) error {
+	if err := tmux.trustScripts(plugin); err != nil {
+		return err
+	}
+	if pending := tmux.pendingHook(plugin); len(pending) != 0 {
+		return fmt.Errorf("%v hook failed, install the plugin again to retry it", pending)
+	}
+	toLoad, err := filepath.Glob(filepath.Join(tmux.path, plugin.directory(), "*.tmux"))
 	if err != nil {
-		return homeDir, err
+		return err
+	}
+	script := &exec.Script{
+		Prefix:  "[" + plugin.name + "]",
+		Timeout: tmux.Timeout,
+		Env:     tmux.Server.Env(),
 	}
-	possibilities := []string{
-		".tmux.conf",
-		".config/tmux/tmux.conf",
+	for _, item := range toLoad {
+		started := time.Now()
+		_, err := script.Run(shellJoin([]string{item}))
+		tmux.record(plugin, filepath.Base(item), started, err)
+		if err != nil {
+			return fmt.Errorf("%v: %v", filepath.Base(item), err)
+		}
+	}
+	return nil
+}
+
+func (plugin *Plugin) directory() string {
+	return filepath.Base(plugin.name)
+}
+
+func (plugin *Plugin) declaration() string {
+	return fmt.Sprintf("%v:%v", plugin.file, plugin.line)
+}
+
+func SelectConfig(explicit string) (string, error) {
+	possibilities, err := configPossibilities(explicit)
+	if err != nil {
+		return "", err
 	}
 	for _, possibility := range possibilities {
-		toCheck := filepath.Join(homeDir, possibility)
-		if utils.VerifyPath(toCheck) {
-			return toCheck, nil
+		if utils.VerifyPath(possibility) {
+			return possibility, nil
 		}
 	}
-	return homeDir, fmt.Errorf("No possible configuration found")
+	return "", fmt.Errorf("No tmux configuration found, checked: %v", strings.Join(possibilities, ", "))
+}
+
+func configPossibilities(explicit string) ([]string, error) {
+	if len(explicit) != 0 {
+		resolved, err := utils.ResolvePath(explicit)
+		return []string{resolved}, err
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	possibilities := []string{filepath.Join(homeDir, ".tmux.conf")}
+	if xdgConfig := os.Getenv("XDG_CONFIG_HOME"); len(xdgConfig) != 0 {
+		possibilities = append(possibilities, filepath.Join(xdgConfig, "tmux", "tmux.conf"))
+	}
+	return append(possibilities, filepath.Join(homeDir, ".config", "tmux", "tmux.conf")), nil
+}
+
+func defaultPluginPath(configPath string) (string, error) {
+	if path := os.Getenv(pluginPathVariable); len(path) != 0 {
+		return utils.ResolvePath(path)
+	}
+	return filepath.Join(filepath.Dir(configPath), "plugins"), nil
 }
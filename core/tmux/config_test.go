@@ -0,0 +1,118 @@
+package tmux
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"source.cyberpi.de/go/teminel/load"
+)
+
+func TestReadConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   string
+		expected []string
+		unparsed int
+	}{
+		{
+			name:     "set option forms",
+			config:   "set -g @plugin 'a/one'\nset-option -g @plugin \"a/two\"\nset @plugin a/three\n",
+			expected: []string{"a/one:1", "a/two:2", "a/three:3"},
+		},
+		{
+			name:     "comments",
+			config:   "# set -g @plugin 'a/ignored'\nset -g @plugin 'a/one' # set -g @plugin 'a/ignored'\n",
+			expected: []string{"a/one:2"},
+		},
+		{
+			name:     "dotted names",
+			config:   "set -g @plugin 'aserowy/tmux.nvim'\nset -g @tmux.nvim.navigation true\n",
+			expected: []string{"aserowy/tmux.nvim:1"},
+		},
+		{
+			name:     "urls",
+			config:   "set -g @plugin 'git@gitlab.com:a/one.git'\nset -g @plugin 'https://gitlab.com/a/two#v1'\n",
+			expected: []string{"a/one:1", "a/two:2"},
+		},
+		{
+			name:     "tpm plugins list",
+			config:   "set -g @tpm_plugins '          \\\n  a/one       \\\n  a/two#main  \\\n'\nset -g @plugin a/three\n",
+			expected: []string{"a/one:1", "a/two:1", "a/three:5"},
+		},
+		{
+			name:     "separators",
+			config:   "set -g status on; set -g @plugin a/one ; set -g @plugin 'a/two'\n",
+			expected: []string{"a/one:1", "a/two:1"},
+		},
+		{
+			name:     "line continuation",
+			config:   "set -g \\\n  @plugin \\\n  a/one\nset -g @plugin a/two\n",
+			expected: []string{"a/one:1", "a/two:4"},
+		},
+		{
+			name:     "unset and missing values",
+			config:   "set -gu @plugin\nset -g @plugin ''\nset -g @plugin a/one\n",
+			expected: []string{"a/one:3"},
+			unparsed: 1,
+		},
+		{
+			name:     "options after a failed declaration",
+			config:   "set -g @plugin a/one\nset -g @plugin 'not a spec'\nset -g @plugin_if_env HOME\nset -g @plugin a/two\nset -g @plugin 'a/three\nset -g @plugin_lazy C-a\n",
+			expected: []string{"a/one:1", "a/two:4"},
+			unparsed: 4,
+		},
+	}
+	for _, test := range tests {
+		tmux := readTestConfig(t, test.config)
+		var declared []string
+		for _, plugin := range tmux.plugins {
+			declared = append(declared, fmt.Sprintf("%v:%v", plugin.name, plugin.line))
+		}
+		if !reflect.DeepEqual(declared, test.expected) {
+			t.Errorf("%v: declared %q, expected %q", test.name, declared, test.expected)
+		}
+		if len(tmux.unparsed) != test.unparsed {
+			t.Errorf("%v: %v unparsed lines, expected %v", test.name, len(tmux.unparsed), test.unparsed)
+		}
+		for _, plugin := range tmux.plugins {
+			if len(plugin.conditions) != 0 || len(plugin.lazy) != 0 {
+				t.Errorf("%v: options attached to %v declared before a failed declaration", test.name, plugin.name)
+			}
+		}
+	}
+}
+
+func TestReadConfigPluginPath(t *testing.T) {
+	t.Setenv(pluginPathVariable, "")
+	tmux := readTestConfig(t, "set -g @plugin a/one\n")
+	if expected := filepath.Join(filepath.Dir(tmux.lockPath), "plugins"); tmux.path != expected {
+		t.Errorf("plugin path %v, expected %v", tmux.path, expected)
+	}
+	tmux = readTestConfig(t, "set-environment -g TMUX_PLUGIN_MANAGER_PATH /opt/tmux/plugins\n")
+	if tmux.path != "/opt/tmux/plugins" {
+		t.Errorf("plugin path %v, expected /opt/tmux/plugins", tmux.path)
+	}
+	t.Setenv(pluginPathVariable, "/srv/plugins")
+	tmux = readTestConfig(t, "set -g @plugin a/one\n")
+	if tmux.path != "/srv/plugins" {
+		t.Errorf("plugin path %v, expected /srv/plugins", tmux.path)
+	}
+}
+
+func readTestConfig(t *testing.T, content string) *Config {
+	t.Helper()
+	configPath := filepath.Join(t.TempDir(), "tmux.conf")
+	if err := os.WriteFile(configPath, []byte(content), os.FileMode(0644)); err != nil {
+		t.Fatal(err)
+	}
+	tmux := &Config{
+		Source: &load.GitSource{Archive: &load.ArchiveSource{Host: "github.com"}},
+	}
+	if err := tmux.Read(configPath); err != nil {
+		t.Fatal(err)
+	}
+	return tmux
+}
@@ -0,0 +1,46 @@
+package tmux
+
+import (
+	"os"
+	"slices"
+	"strings"
+
+	"source.cyberpi.de/go/teminel/utils"
+)
+
+const disabledReason = "disabled"
+
+func readDisabled(path string) (map[string]bool, error) {
+	disabled := make(map[string]bool)
+	if !utils.VerifyPath(path) {
+		return disabled, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if name := strings.TrimSpace(line); len(name) != 0 && !strings.HasPrefix(name, "#") {
+			disabled[name] = true
+		}
+	}
+	return disabled, nil
+}
+
+func (tmux *Config) toggleDisabled(plugin *Plugin) error {
+	if tmux.disabled[plugin.name] {
+		delete(tmux.disabled, plugin.name)
+	} else {
+		tmux.disabled[plugin.name] = true
+	}
+	plugin.skip = tmux.skipReason(plugin)
+	var names []string
+	for name := range tmux.disabled {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	if len(names) == 0 {
+		return os.RemoveAll(tmux.disabledPath)
+	}
+	return os.WriteFile(tmux.disabledPath, []byte(strings.Join(names, "\n")+"\n"), os.FileMode(0644))
+}
@@ -0,0 +1,82 @@
+package tmux
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"source.cyberpi.de/go/teminel/exec"
+	"source.cyberpi.de/go/teminel/load"
+)
+
+func (tmux *Config) runHooks(result *Result) *Result {
+	if result.Err != nil {
+		return result
+	}
+	kind, command := result.Plugin.hook(result.Revision.Status)
+	if pending := tmux.pendingHook(result.Plugin); len(pending) != 0 && (len(command) == 0 || pending == "post-install") {
+		fmt.Println("Retrying failed", pending, "hook of plugin:", result.Plugin.name)
+		kind, command = pending, result.Plugin.hookCommand(pending)
+	}
+	if len(command) == 0 {
+		return tmux.clearHook(result)
+	}
+	fmt.Println("Running", kind, "hook of plugin:", result.Plugin.name)
+	script := &exec.Script{
+		Prefix:  "[" + result.Plugin.name + " " + kind + "]",
+		Timeout: tmux.HookTimeout,
+		Dir:     filepath.Join(tmux.path, result.Plugin.directory()),
+	}
+	status, err := script.Run(command)
+	if err != nil {
+		if length := len(status.Output); length != 0 {
+			err = fmt.Errorf("%v: %v", err, status.Output[length-1])
+		}
+		result.Err = fmt.Errorf("%v hook failed: %v", kind, err)
+		if err := os.WriteFile(tmux.hookMarker(result.Plugin), []byte(kind+"\n"), os.FileMode(0644)); err != nil {
+			fmt.Println("Unable to record failed hook of plugin:", result.Plugin.name, err)
+		}
+		return result
+	}
+	return tmux.clearHook(result)
+}
+
+func (tmux *Config) clearHook(result *Result) *Result {
+	if err := os.Remove(tmux.hookMarker(result.Plugin)); err != nil && !os.IsNotExist(err) {
+		result.Err = err
+	}
+	return result
+}
+
+func (tmux *Config) hookMarker(plugin *Plugin) string {
+	return filepath.Join(tmux.path, "."+plugin.directory()+".failed-hook")
+}
+
+func (tmux *Config) pendingHook(plugin *Plugin) string {
+	data, err := os.ReadFile(tmux.hookMarker(plugin))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func (plugin *Plugin) hook(status string) (string, string) {
+	switch status {
+	case load.StatusCloned, load.StatusDownloaded, statusLinked, statusCopied:
+		return "post-install", plugin.postInstall
+	case load.StatusUpdated:
+		return "post-update", plugin.postUpdate
+	}
+	return "", ""
+}
+
+func (plugin *Plugin) hookCommand(kind string) string {
+	switch kind {
+	case "post-install":
+		return plugin.postInstall
+	case "post-update":
+		return plugin.postUpdate
+	}
+	return ""
+}
@@ -0,0 +1,59 @@
+package tmux
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"source.cyberpi.de/go/teminel/load"
+)
+
+func TestRunHooksRetriesFailedHook(t *testing.T) {
+	directory := t.TempDir()
+	built := filepath.Join(directory, "built")
+	plugin := &Plugin{name: "owner/alpha", postInstall: "test -f " + built, postUpdate: "true"}
+	tmux := &Config{path: directory}
+	if err := os.Mkdir(filepath.Join(directory, plugin.directory()), os.FileMode(0755)); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		status  string
+		build   bool
+		fails   bool
+		pending string
+	}{
+		{name: "failed install hook", status: load.StatusCloned, fails: true, pending: "post-install"},
+		{name: "retried on an unchanged checkout", status: load.StatusUpToDate, fails: true, pending: "post-install"},
+		{name: "retried instead of the update hook", status: load.StatusUpdated, fails: true, pending: "post-install"},
+		{name: "retried until it succeeds", status: load.StatusUpToDate, build: true},
+		{name: "no hook left to run", status: load.StatusUpToDate},
+	}
+	for _, test := range tests {
+		if test.build {
+			if err := os.WriteFile(built, nil, os.FileMode(0644)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		result := tmux.runHooks(&Result{Plugin: plugin, Revision: &load.Revision{Status: test.status}})
+		if test.fails != (result.Err != nil) {
+			t.Errorf("%v: hooks returned error %v", test.name, result.Err)
+		}
+		if pending := tmux.pendingHook(plugin); pending != test.pending {
+			t.Errorf("%v: pending hook %q, expected %q", test.name, pending, test.pending)
+		}
+	}
+}
+
+func TestLoadPluginWithFailedHook(t *testing.T) {
+	directory := t.TempDir()
+	plugin := &Plugin{name: "owner/alpha", postInstall: "false"}
+	tmux := &Config{path: directory}
+	if err := os.Mkdir(filepath.Join(directory, plugin.directory()), os.FileMode(0755)); err != nil {
+		t.Fatal(err)
+	}
+	tmux.runHooks(&Result{Plugin: plugin, Revision: &load.Revision{Status: load.StatusCloned}})
+	if err := tmux.loadPlugin(plugin); err == nil {
+		t.Errorf("loading a plugin with a failed post-install hook succeeded")
+	}
+}
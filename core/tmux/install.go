@@ -0,0 +1,123 @@
+package tmux
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"source.cyberpi.de/go/teminel/load"
+	"source.cyberpi.de/go/teminel/utils"
+)
+
+func (tmux *Config) Install() error {
+	if err := utils.EnsureDirectory(tmux.path); err != nil {
+		return err
+	}
+	if _, err := tmux.ordered(); err != nil {
+		return err
+	}
+	results := tmux.installAll(tmux.active(), tmux.ensure)
+	if err := tmux.writeLock(results); err != nil {
+		return err
+	}
+	return Report(results)
+}
+
+func (tmux *Config) ensure(plugin *Plugin) *Result {
+	if len(plugin.local) != 0 {
+		return tmux.linkLocal(plugin)
+	}
+	source := tmux.source(plugin)
+	revision, err := source.EnsureRepository(plugin.name, tmux.path, tmux.reference(plugin))
+	return &Result{Plugin: plugin, Revision: revision, Err: err}
+}
+
+func (tmux *Config) source(plugin *Plugin) *load.GitSource {
+	source := tmux.Source.WithHost(plugin.host)
+	source.URL = plugin.url
+	return source
+}
+
+func (tmux *Config) writeLock(results []*Result) error {
+	previous, err := ReadLock(tmux.lockPath)
+	if err != nil {
+		return err
+	}
+	installed := make(map[*Plugin]*load.Revision, len(results))
+	for _, result := range results {
+		if result.Err == nil {
+			installed[result.Plugin] = result.Revision
+		}
+	}
+	lock := &Lock{}
+	for _, plugin := range tmux.plugins {
+		if len(plugin.local) != 0 {
+			continue
+		}
+		if revision, found := installed[plugin]; found {
+			lock.Plugins = append(lock.Plugins, newLockEntry(plugin, revision))
+		} else if entry := previous.Find(plugin.host, plugin.name); entry != nil {
+			lock.Plugins = append(lock.Plugins, entry)
+		}
+	}
+	fmt.Println("Writing lockfile:", tmux.lockPath)
+	return lock.Write(tmux.lockPath)
+}
+
+func (tmux *Config) InstallFrozen() error {
+	lock, err := ReadLock(tmux.lockPath)
+	if err != nil {
+		return err
+	}
+	if err := checkDirectories(tmux.active()); err != nil {
+		return err
+	}
+	if err := utils.EnsureDirectory(tmux.path); err != nil {
+		return err
+	}
+	results := tmux.installAll(tmux.active(), func(plugin *Plugin) *Result {
+		if len(plugin.local) != 0 {
+			return tmux.linkLocal(plugin)
+		}
+		entry := lock.Find(plugin.host, plugin.name)
+		if entry == nil {
+			return &Result{Plugin: plugin, Err: fmt.Errorf("Not part of the lockfile: %v", tmux.lockPath)}
+		}
+		if len(plugin.reference) != 0 && entry.Branch != plugin.reference {
+			return &Result{Plugin: plugin, Err: fmt.Errorf("Pinned to %v but locked at %v", plugin.reference, entry.Branch)}
+		}
+		if required := tmux.requiredCommit(plugin); len(required) != 0 && len(entry.Tarball) == 0 && !strings.HasPrefix(entry.Commit, required) {
+			return &Result{Plugin: plugin, Err: fmt.Errorf("Locked at %.12v but required commit is %v", entry.Commit, required)}
+		}
+		source := tmux.source(plugin)
+		revision, err := source.EnsureRevision(plugin.name, tmux.path, entry.revision())
+		return &Result{Plugin: plugin, Revision: revision, Err: err}
+	})
+	return Report(results)
+}
+
+func (tmux *Config) installAll(plugins []*Plugin, install func(*Plugin) *Result) []*Result {
+	results := make([]*Result, len(plugins))
+	indexes := make(chan int)
+	var group sync.WaitGroup
+	for worker := 0; worker < max(tmux.Jobs, 1); worker++ {
+		group.Add(1)
+		go func() {
+			defer group.Done()
+			for index := range indexes {
+				if err := tmux.precheck(plugins[index]); err != nil {
+					results[index] = &Result{Plugin: plugins[index], Err: err}
+				} else {
+					results[index] = tmux.runHooks(install(plugins[index]))
+				}
+				tmux.notify("%v %v", results[index].Plugin.name, results[index].status())
+			}
+		}()
+	}
+	for index := range plugins {
+		indexes <- index
+	}
+	close(indexes)
+	group.Wait()
+	return results
+}
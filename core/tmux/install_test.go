@@ -0,0 +1,35 @@
+package tmux
+
+import (
+	"testing"
+
+	"source.cyberpi.de/go/teminel/load"
+)
+
+func TestInstallAllSharedDirectory(t *testing.T) {
+	tmux := readTestConfig(t, "set -g @plugin a/tmux\nset -g @plugin b/tmux\nset -g @plugin c/other\n")
+	tests := []struct {
+		name    string
+		plugins []*Plugin
+		failed  map[string]bool
+	}{
+		{name: "all plugins", plugins: tmux.plugins, failed: map[string]bool{"a/tmux": true, "b/tmux": true}},
+		{name: "single plugin sharing a directory", plugins: tmux.plugins[1:2], failed: map[string]bool{"b/tmux": true}},
+		{name: "single unrelated plugin", plugins: tmux.plugins[2:], failed: map[string]bool{}},
+	}
+	for _, test := range tests {
+		installed := 0
+		results := tmux.installAll(test.plugins, func(plugin *Plugin) *Result {
+			installed++
+			return &Result{Plugin: plugin, Revision: &load.Revision{Status: load.StatusCloned}}
+		})
+		for _, result := range results {
+			if failed := result.Err != nil; failed != test.failed[result.Plugin.name] {
+				t.Errorf("%v: installing %v returned error %v", test.name, result.Plugin.name, result.Err)
+			}
+		}
+		if expected := len(test.plugins) - len(test.failed); installed != expected {
+			t.Errorf("%v: installed %v plugins, expected %v", test.name, installed, expected)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package tmux
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+func (tmux *Config) start(plugin *Plugin) error {
+	if len(plugin.lazy) != 0 {
+		return tmux.bindLazy(plugin)
+	}
+	return tmux.loadPlugin(plugin)
+}
+
+func (tmux *Config) bindLazy(plugin *Plugin) error {
+	command := append(append([]string{}, tmux.Command...), "-lazy", plugin.name)
+	for _, key := range plugin.lazy {
+		fmt.Println("Binding key:", key, "to lazy load plugin:", plugin.name)
+		script := shellJoin(append(command, "-key", key)) + " >/dev/null 2>&1"
+		if _, err := tmux.Server.Tmux("bind-key", key, "run-shell", "-b", script); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (tmux *Config) LoadLazy(name string, key string) error {
+	plugin := tmux.findPlugin(name)
+	if plugin == nil {
+		return fmt.Errorf("Plugin is not declared in the config: %v", name)
+	}
+	if err := tmux.requirement(plugin); err != nil {
+		return err
+	}
+	placeholders := make(map[string]string, len(plugin.lazy))
+	for _, lazyKey := range plugin.lazy {
+		placeholders[lazyKey] = tmux.listKey(lazyKey)
+	}
+	fmt.Println("Start lazy plugin:", plugin.name, "declared at:", plugin.declaration())
+	if err := tmux.loadPlugin(plugin); err != nil {
+		message := fmt.Sprintf("teminel: plugin failed to load: %v", plugin.name)
+		if _, err := tmux.Server.Tmux("display-message", message); err != nil {
+			fmt.Println("Unable to report failed plugin:", err)
+		}
+		return err
+	}
+	for lazyKey, placeholder := range placeholders {
+		binding := tmux.listKey(lazyKey)
+		if binding == placeholder {
+			fmt.Println("Plugin did not bind key, removing placeholder:", lazyKey)
+			if _, err := tmux.Server.Tmux("unbind-key", lazyKey); err != nil {
+				return err
+			}
+			continue
+		}
+		if lazyKey == key && len(binding) != 0 {
+			if err := tmux.dispatch(binding); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (tmux *Config) listKey(key string) string {
+	output, err := tmux.Server.Tmux("list-keys", "-T", "prefix", key)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(output)
+}
+
+func (tmux *Config) dispatch(binding string) error {
+	command, err := bindingCommand(binding)
+	if err != nil {
+		return err
+	}
+	fmt.Println("Dispatching key binding:", command)
+	file, err := os.CreateTemp("", "teminel-lazy-*.conf")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	if _, err := file.WriteString(command + "\n"); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	_, err = tmux.Server.Tmux("source-file", file.Name())
+	return err
+}
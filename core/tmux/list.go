@@ -0,0 +1,134 @@
+package tmux
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"source.cyberpi.de/go/teminel/load"
+	"source.cyberpi.de/go/teminel/utils"
+)
+
+type ListEntry struct {
+	Name      string   `json:"name"`
+	Host      string   `json:"host,omitempty"`
+	File      string   `json:"file"`
+	Line      int      `json:"line"`
+	Path      string   `json:"path"`
+	Installed bool     `json:"installed"`
+	Method    string   `json:"method,omitempty"`
+	Branch    string   `json:"branch,omitempty"`
+	Commit    string   `json:"commit,omitempty"`
+	Tarball   string   `json:"tarball,omitempty"`
+	Local     string   `json:"local,omitempty"`
+	Scripts   []string `json:"scripts"`
+	Skipped   string   `json:"skipped,omitempty"`
+	Profiles  []string `json:"profiles,omitempty"`
+}
+
+func (tmux *Config) List(writer io.Writer, asJson bool) error {
+	lock, err := ReadLock(tmux.lockPath)
+	if err != nil {
+		return err
+	}
+	entries := make([]*ListEntry, len(tmux.plugins))
+	for index, plugin := range tmux.plugins {
+		entries[index], err = tmux.inspect(plugin, lock)
+		if err != nil {
+			return err
+		}
+	}
+	if asJson {
+		encoder := json.NewEncoder(writer)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(entries)
+	}
+	table := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "PLUGIN\tHOST\tDECLARED\tPATH\tMETHOD\tREVISION\tSCRIPTS\tSTATE")
+	for _, entry := range entries {
+		fmt.Fprintf(table, "%v\t%v\t%v:%v\t%v\t%v\t%v\t%v\t%v\n",
+			entry.Name,
+			entry.Host,
+			entry.File,
+			entry.Line,
+			entry.Path,
+			entry.Method,
+			entry.revision(),
+			len(entry.Scripts),
+			entry.state(),
+		)
+	}
+	return table.Flush()
+}
+
+func (tmux *Config) inspect(plugin *Plugin, lock *Lock) (*ListEntry, error) {
+	path := filepath.Join(tmux.path, plugin.directory())
+	entry := &ListEntry{
+		Name:     plugin.name,
+		Host:     plugin.host,
+		File:     plugin.file,
+		Line:     plugin.line,
+		Path:     path,
+		Local:    plugin.local,
+		Skipped:  plugin.skip,
+		Profiles: plugin.profiles,
+		Scripts:  []string{},
+	}
+	if !utils.VerifyPath(path) {
+		return entry, nil
+	}
+	entry.Installed = true
+	scripts, err := filepath.Glob(filepath.Join(path, "*.tmux"))
+	if err != nil {
+		return nil, err
+	}
+	for _, script := range scripts {
+		entry.Scripts = append(entry.Scripts, filepath.Base(script))
+	}
+	if len(plugin.local) != 0 {
+		entry.Method = methodLocal
+		return entry, nil
+	}
+	revision, err := load.Inspect(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Unable to inspect plugin:", plugin.name, err)
+		return entry, nil
+	}
+	entry.Method = revision.Method
+	entry.Branch = revision.Branch
+	entry.Commit = revision.Commit
+	if locked := lock.Find(plugin.host, plugin.name); locked != nil && len(locked.Tarball) != 0 {
+		entry.Branch = locked.Branch
+		entry.Commit = ""
+		entry.Tarball = locked.Tarball
+	}
+	return entry, nil
+}
+
+func (entry *ListEntry) revision() string {
+	switch {
+	case len(entry.Local) != 0:
+		return entry.Local
+	case len(entry.Tarball) != 0:
+		return entry.Tarball
+	case len(entry.Commit) != 0:
+		return fmt.Sprintf("%v@%.12v", entry.Branch, entry.Commit)
+	}
+	return "-"
+}
+
+func (entry *ListEntry) state() string {
+	switch {
+	case len(entry.Skipped) != 0:
+		return "skipped: " + entry.Skipped
+	case !entry.Installed:
+		return "not installed"
+	case len(entry.Scripts) == 0:
+		return "no scripts"
+	}
+	return "installed " + strings.Join(entry.Scripts, ",")
+}
@@ -0,0 +1,79 @@
+package tmux
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"source.cyberpi.de/go/teminel/load"
+	"source.cyberpi.de/go/teminel/utils"
+)
+
+const (
+	methodLocal  = "local"
+	statusLinked = "linked"
+	statusCopied = "copied"
+
+	localCopyMarker = ".teminel-local"
+)
+
+func (tmux *Config) linkLocal(plugin *Plugin) *Result {
+	revision, err := linkDirectory(plugin.local, filepath.Join(tmux.path, plugin.directory()))
+	return &Result{Plugin: plugin, Revision: revision, Err: err}
+}
+
+func linkDirectory(source string, target string) (*load.Revision, error) {
+	if !utils.VerifyPath(source) {
+		return nil, fmt.Errorf("Local plugin directory does not exist: %v", source)
+	}
+	revision := &load.Revision{
+		Method: methodLocal,
+		Status: load.StatusUpToDate,
+	}
+	if samePath(source, target) {
+		return revision, nil
+	}
+	if err := removeLocal(target); err != nil {
+		return nil, err
+	}
+	fmt.Println("Linking local plugin:", source, "to:", target)
+	err := os.Symlink(source, target)
+	if err == nil {
+		revision.Status = statusLinked
+		return revision, nil
+	}
+	fmt.Println("Unable to link local plugin, copying instead:", err)
+	if err := utils.CopyDirectory(source, target); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(target, localCopyMarker), []byte(source+"\n"), os.FileMode(0644)); err != nil {
+		return nil, err
+	}
+	revision.Status = statusCopied
+	return revision, nil
+}
+
+func samePath(source string, target string) bool {
+	resolvedSource, err := filepath.EvalSymlinks(source)
+	if err != nil {
+		return false
+	}
+	resolvedTarget, err := filepath.EvalSymlinks(target)
+	return err == nil && resolvedSource == resolvedTarget
+}
+
+func removeLocal(target string) error {
+	info, err := os.Lstat(target)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		return os.Remove(target)
+	}
+	if !utils.VerifyPath(filepath.Join(target, localCopyMarker)) {
+		return fmt.Errorf("Refusing to replace %v, it was not linked or copied by teminel", target)
+	}
+	return os.RemoveAll(target)
+}
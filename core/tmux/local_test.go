@@ -0,0 +1,104 @@
+package tmux
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"source.cyberpi.de/go/teminel/load"
+)
+
+func TestLinkDirectory(t *testing.T) {
+	tests := []struct {
+		name    string
+		prepare func(source string, target string) error
+		inPlace bool
+		status  string
+		fails   bool
+	}{
+		{
+			name:    "missing target",
+			prepare: func(source string, target string) error { return nil },
+			status:  statusLinked,
+		},
+		{
+			name:    "existing link",
+			prepare: func(source string, target string) error { return os.Symlink(source, target) },
+			status:  load.StatusUpToDate,
+		},
+		{
+			name: "stale link",
+			prepare: func(source string, target string) error {
+				return os.Symlink(filepath.Dir(source), target)
+			},
+			status: statusLinked,
+		},
+		{
+			name:    "source inside the plugin path",
+			prepare: func(source string, target string) error { return nil },
+			inPlace: true,
+			status:  load.StatusUpToDate,
+		},
+		{
+			name: "copy made by teminel",
+			prepare: func(source string, target string) error {
+				if err := os.Mkdir(target, os.FileMode(0755)); err != nil {
+					return err
+				}
+				return os.WriteFile(filepath.Join(target, localCopyMarker), []byte(source), os.FileMode(0644))
+			},
+			status: statusLinked,
+		},
+		{
+			name: "foreign directory",
+			prepare: func(source string, target string) error {
+				if err := os.Mkdir(target, os.FileMode(0755)); err != nil {
+					return err
+				}
+				return os.WriteFile(filepath.Join(target, "mine.tmux"), []byte("#!/bin/sh\n"), os.FileMode(0755))
+			},
+			fails: true,
+		},
+	}
+	for _, test := range tests {
+		directory := t.TempDir()
+		plugins := filepath.Join(directory, "plugins")
+		source := filepath.Join(directory, "src", "mine")
+		if test.inPlace {
+			source = filepath.Join(plugins, "mine")
+		}
+		target := filepath.Join(plugins, "mine")
+		if err := os.MkdirAll(source, os.FileMode(0755)); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.MkdirAll(plugins, os.FileMode(0755)); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(source, "mine.tmux"), []byte("#!/bin/sh\n"), os.FileMode(0755)); err != nil {
+			t.Fatal(err)
+		}
+		if err := test.prepare(source, target); err != nil {
+			t.Fatal(err)
+		}
+		revision, err := linkDirectory(source, target)
+		if _, err := os.Stat(filepath.Join(source, "mine.tmux")); err != nil {
+			t.Errorf("%v: source of the plugin was removed: %v", test.name, err)
+		}
+		if test.fails {
+			if err == nil {
+				t.Errorf("%v: linking succeeded with status %v, expected an error", test.name, revision.Status)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: linking failed: %v", test.name, err)
+			continue
+		}
+		if revision.Status != test.status {
+			t.Errorf("%v: linked with status %v, expected %v", test.name, revision.Status, test.status)
+		}
+		if !samePath(source, target) {
+			t.Errorf("%v: %v does not point to %v", test.name, target, source)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package tmux
+
+import (
+	"encoding/json"
+	"os"
+
+	"source.cyberpi.de/go/teminel/load"
+	"source.cyberpi.de/go/teminel/utils"
+)
+
+type LockEntry struct {
+	Host    string `json:"host"`
+	Name    string `json:"name"`
+	Branch  string `json:"branch,omitempty"`
+	Commit  string `json:"commit,omitempty"`
+	Tarball string `json:"tarball,omitempty"`
+	Digest  string `json:"digest,omitempty"`
+}
+
+type Lock struct {
+	Plugins []*LockEntry `json:"plugins"`
+}
+
+func ReadLock(path string) (*Lock, error) {
+	lock := &Lock{}
+	if !utils.VerifyPath(path) {
+		return lock, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, lock); err != nil {
+		return nil, err
+	}
+	return lock, nil
+}
+
+func (lock *Lock) Write(path string) error {
+	data, err := json.MarshalIndent(lock, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), os.FileMode(0644))
+}
+
+func (lock *Lock) Find(host string, name string) *LockEntry {
+	for _, entry := range lock.Plugins {
+		if entry.Host == host && entry.Name == name {
+			return entry
+		}
+	}
+	return nil
+}
+
+func newLockEntry(plugin *Plugin, revision *load.Revision) *LockEntry {
+	return &LockEntry{
+		Host:    plugin.host,
+		Name:    plugin.name,
+		Branch:  revision.Branch,
+		Commit:  revision.Commit,
+		Tarball: revision.Tarball,
+		Digest:  revision.Digest,
+	}
+}
+
+func (entry *LockEntry) revision() *load.Revision {
+	return &load.Revision{
+		Branch:  entry.Branch,
+		Commit:  entry.Commit,
+		Tarball: entry.Tarball,
+		Digest:  entry.Digest,
+	}
+}
@@ -2,8 +2,12 @@ package tmux
 
 import (
 	"flag"
+	"fmt"
 	"os"
+	"slices"
+	"time"
 
+	"source.cyberpi.de/go/teminel/exec"
 	extFlag "source.cyberpi.de/go/teminel/flag"
 	"source.cyberpi.de/go/teminel/load"
 	"source.cyberpi.de/go/teminel/utils"
@@ -14,10 +18,8 @@ func Main() {
 }
 
 func main() {
-	configFile, err := SelectConfig()
-	if err != nil {
-		panic(err)
-	}
+	configFile := utils.EnsureEnv("TEMINEL_TMUX_CONFIG", "")
+	flag.StringVar(&configFile, "config", configFile, "Path to the tmux config file.")
 	backend := utils.EnsureEnv("TEMINEL_BACKEND", "github.com")
 	flag.StringVar(&backend, "backend", backend, "Backend server.")
 	var versions extFlag.MultiFlag
@@ -32,10 +34,94 @@ func main() {
 
 	install := false
 	flag.BoolVar(&install, "install", install, "Runs the installation of plufins")
+	update := false
+	flag.BoolVar(&update, "update", update, "Updates all or the named plugins and shows their changes")
+	clean := false
+	flag.BoolVar(&clean, "clean", clean, "Removes plugins which are no longer declared in the config")
+	dryRun := false
+	flag.BoolVar(&dryRun, "dry-run", dryRun, "Lists the changes of clean without removing anything")
+	jobs := 4
+	flag.IntVar(&jobs, "jobs", jobs, "Number of plugins to install in parallel")
+	frozen := false
+	flag.BoolVar(&frozen, "frozen", frozen, "Installs the plugins exactly as pinned in the lockfile")
+	list := false
+	flag.BoolVar(&list, "list", list, "Lists the declared plugins and their install state")
+	asJson := false
+	flag.BoolVar(&asJson, "json", asJson, "Prints the list as JSON, logs are written to stderr")
+	exportPath := ""
+	flag.StringVar(&exportPath, "export", exportPath, "Packs the installed plugins and the lockfile into a bundle")
+	importPath := ""
+	flag.StringVar(&importPath, "import", importPath, "Restores the plugins from a bundle without network access")
+	timeout := 30 * time.Second
+	flag.DurationVar(&timeout, "timeout", timeout, "Maximum runtime of a single plugin script")
+	hookTimeout := 5 * time.Minute
+	flag.DurationVar(&hookTimeout, "hook-timeout", hookTimeout, "Maximum runtime of a plugin post install or update hook")
+	timings := false
+	flag.BoolVar(&timings, "timings", timings, "Measures the runtime of every plugin script and prints a report")
+	timingsJson := ""
+	flag.StringVar(&timingsJson, "timings-json", timingsJson, "Writes the measured script runtimes as JSON to the given file")
+	migrate := false
+	flag.BoolVar(&migrate, "migrate", migrate, "Adopts the plugins of an existing TPM installation")
+	removeTpm := false
+	flag.BoolVar(&removeTpm, "remove-tpm", removeTpm, "Comments out the TPM run line during the migration")
+	watch := false
+	flag.BoolVar(&watch, "watch", watch, "Keeps running and installs plugins added to the config")
+	watchInterval := 2 * time.Second
+	flag.DurationVar(&watchInterval, "watch-interval", watchInterval, "Interval to check the config files for changes")
+	lazy := ""
+	flag.StringVar(&lazy, "lazy", lazy, "Loads the named lazy plugin and dispatches the pressed key")
+	lazyKey := ""
+	flag.StringVar(&lazyKey, "key", lazyKey, "Key which triggered the lazy plugin")
+	ui := false
+	flag.BoolVar(&ui, "ui", ui, "Opens the interactive plugin manager")
+	plan := false
+	flag.BoolVar(&plan, "plan", plan, "Prints the planned actions per plugin without changing anything")
+	socket := ""
+	flag.StringVar(&socket, "socket", socket, "Path to the socket of the tmux server to load the plugins into")
+	flag.StringVar(&socket, "S", socket, "Shorthand for -socket")
+	socketName := ""
+	flag.StringVar(&socketName, "L", socketName, "Name of the tmux server socket like tmux -L")
+	allServers := false
+	flag.BoolVar(&allServers, "all-servers", allServers, "Loads the plugins into all running tmux servers of the user")
+	policy := utils.EnsureEnv("TEMINEL_POLICY", "")
+	flag.StringVar(&policy, "policy", policy, "Path to the trust policy, defaults to the config path with .policy.json")
+	allowUntrusted := false
+	flag.BoolVar(&allowUntrusted, "allow-untrusted", allowUntrusted, "Installs and loads plugins the trust policy refuses")
+	profile := utils.EnsureEnv("TEMINEL_PROFILE", "")
+	flag.StringVar(&profile, "profile", profile, "Selects the plugin profile to install and load")
+	notify := false
+	flag.BoolVar(&notify, "notify", notify, "Reports progress with tmux display-message")
 
 	flag.Parse()
 
+	output := os.Stdout
+	if asJson {
+		os.Stdout = os.Stderr
+	}
+	configFile, err := SelectConfig(configFile)
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+	server, err := selectServer(socket, socketName)
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+	if err := CheckProfile(profile); err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+	trustPolicy, err := ReadPolicy(policy, configFile)
+	if err != nil {
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
 	config := Config{
+		Server:         server,
+		Policy:         trustPolicy,
+		AllowUntrusted: allowUntrusted,
+		Profile:        profile,
 		Source: &load.GitSource{
 			Archive: &load.ArchiveSource{
 				Host:        backend,
@@ -45,22 +131,162 @@ func main() {
 			},
 			Protocols: protocols,
 		},
+		Jobs:        jobs,
+		Command:     selectCommand("install", "update", "clean", "dry-run", "frozen", "notify", "export", "import", "timings", "timings-json", "migrate", "remove-tpm", "watch", "watch-interval", "all-servers", "ui", "lazy", "key", "allow-untrusted"),
+		Notify:      notify,
+		Timeout:     timeout,
+		HookTimeout: hookTimeout,
 	}
 	err = config.Read(configFile)
 	if err != nil {
 		panic(err)
 	}
-	if install {
+	if list {
+		if err := config.List(output, asJson); err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(lazy) != 0 {
+		if err := config.LoadLazy(lazy, lazyKey); err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if ui {
+		if err := config.UI(); err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if plan {
+		if err := config.Plan(output); err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if migrate {
+		err = config.Migrate(removeTpm)
+	} else if len(importPath) != 0 {
+		err = config.Import(importPath)
+	} else if frozen {
+		err = config.InstallFrozen()
+	} else if update {
+		err = config.Update(flag.Args()...)
+	} else if install {
 		err = config.Install()
+	}
+	if err == nil && clean {
+		err = config.Clean(dryRun)
+	}
+	if err == nil && len(exportPath) != 0 {
+		err = config.Export(exportPath)
+	}
+	if err != nil {
+		config.notify("failed: %v", err)
+		fmt.Println("Error:", err)
+		os.Exit(1)
+	}
+	config.notify("finished")
+	servers := []*exec.Server{}
+	if allServers {
+		if servers, err = exec.Servers(); err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+	} else if server != nil {
+		servers = append(servers, server)
+	}
+	if len(servers) == 0 && (timings || len(timingsJson) != 0) {
+		fmt.Println("Warning: Timings are only measured when plugins are loaded inside tmux")
+	}
+	if len(servers) != 0 {
+		err := config.loadServers(servers)
+		config.Server = server
+		if timings {
+			if err := config.PrintTimings(os.Stdout); err != nil {
+				fmt.Println("Unable to print timings:", err)
+			}
+		}
+		if len(timingsJson) != 0 {
+			if err := config.WriteTimings(timingsJson); err != nil {
+				fmt.Println("Unable to write timings:", err)
+			}
+		}
 		if err != nil {
-			panic(err)
+			fmt.Println("Error:", err)
+			if !watch {
+				os.Exit(1)
+			}
 		}
 	}
-	_, isTmuxSession := os.LookupEnv("TMUX")
-	if isTmuxSession {
-		err := config.Load()
+	if watch {
+		if err := config.Watch(configFile, watchInterval, server != nil); err != nil {
+			fmt.Println("Error:", err)
+			os.Exit(1)
+		}
+	}
+}
+
+func selectServer(socket string, name string) (*exec.Server, error) {
+	var server *exec.Server
+	if len(socket) != 0 {
+		resolved, err := utils.ResolvePath(socket)
 		if err != nil {
-			panic(err)
+			return nil, err
+		}
+		server = &exec.Server{Socket: resolved}
+	} else if len(name) != 0 {
+		server = exec.NamedServer(name)
+	} else if value, found := os.LookupEnv("TMUX"); found {
+		return exec.ParseServer(value)
+	} else {
+		return nil, nil
+	}
+	if err := server.Resolve(); err != nil {
+		return nil, fmt.Errorf("Unable to reach tmux server %v: %v", server.Socket, err)
+	}
+	return server, nil
+}
+
+func (tmux *Config) loadServers(servers []*exec.Server) error {
+	failed := 0
+	for _, server := range servers {
+		fmt.Println("Loading plugins into tmux server:", server.Socket)
+		tmux.Server = server
+		if err := tmux.Load(); err != nil {
+			fmt.Println("Error:", server.Socket, err)
+			failed++
 		}
 	}
+	if failed != 0 {
+		return fmt.Errorf("Loading plugins failed on %v of %v tmux servers", failed, len(servers))
+	}
+	return nil
+}
+
+func selectCommand(modes ...string) []string {
+	executable, err := os.Executable()
+	if err != nil {
+		fmt.Println("Unable to resolve teminel executable:", err)
+		return nil
+	}
+	command := []string{executable}
+	flag.Visit(func(current *flag.Flag) {
+		if slices.Contains(modes, current.Name) {
+			return
+		}
+		if values, ok := current.Value.(*extFlag.MultiFlag); ok {
+			for _, value := range *values {
+				command = append(command, fmt.Sprintf("-%v=%v", current.Name, value))
+			}
+			return
+		}
+		command = append(command, fmt.Sprintf("-%v=%v", current.Name, current.Value))
+	})
+	return command
 }
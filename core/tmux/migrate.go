@@ -0,0 +1,173 @@
+package tmux
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"source.cyberpi.de/go/teminel/load"
+	"source.cyberpi.de/go/teminel/utils"
+)
+
+const statusAdopted = "adopted"
+
+var tpmRunMatcher = regexp.MustCompile(`^\s*run(-shell)?\s+(-b\s+)?['"]?\S*tpm/tpm['"]?\s*$`)
+
+type tpmLine struct {
+	file string
+	line int
+}
+
+func (tmux *Config) Migrate(removeTpm bool) error {
+	tpmPath, err := tmux.findTpm()
+	if err != nil {
+		return err
+	}
+	fmt.Println("Migrating TPM plugins from:", tpmPath)
+	var results []*Result
+	for _, plugin := range tmux.active() {
+		if len(plugin.local) != 0 {
+			continue
+		}
+		if plugin.directory() == "tpm" {
+			fmt.Println("Warning: TPM itself is declared as plugin at", plugin.declaration()+", the declaration can be removed")
+			continue
+		}
+		result := tmux.adopt(plugin, tpmPath)
+		if result != nil {
+			results = append(results, result)
+		}
+	}
+	tmux.reportUnknown(tpmPath)
+	if err := tmux.writeLock(results); err != nil {
+		return err
+	}
+	if err := tmux.migrateRunLines(removeTpm); err != nil {
+		return err
+	}
+	return Report(results)
+}
+
+func (tmux *Config) findTpm() (string, error) {
+	possibilities := []string{tmux.path}
+	if homeDir, err := os.UserHomeDir(); err == nil {
+		possibilities = append(possibilities, filepath.Join(homeDir, ".tmux", "plugins"))
+	}
+	for _, possibility := range possibilities {
+		if utils.VerifyPath(filepath.Join(possibility, "tpm")) {
+			return possibility, nil
+		}
+	}
+	return "", fmt.Errorf("No TPM installation found, checked: %v", strings.Join(possibilities, ", "))
+}
+
+func (tmux *Config) adopt(plugin *Plugin, tpmPath string) *Result {
+	target := filepath.Join(tmux.path, plugin.directory())
+	if !utils.VerifyPath(target) {
+		existing := filepath.Join(tpmPath, plugin.directory())
+		if !utils.VerifyPath(existing) {
+			fmt.Println("Plugin was not installed by TPM, install it with -install:", plugin.name)
+			return nil
+		}
+		if err := utils.EnsureDirectory(tmux.path); err != nil {
+			return &Result{Plugin: plugin, Err: err}
+		}
+		fmt.Println("Moving plugin:", existing, "to:", target)
+		if err := os.Rename(existing, target); err != nil {
+			return &Result{Plugin: plugin, Err: err}
+		}
+	}
+	revision, err := load.Inspect(target)
+	if err != nil {
+		return &Result{Plugin: plugin, Err: fmt.Errorf("Unable to adopt %v: %v", target, err)}
+	}
+	if len(plugin.reference) != 0 && revision.Branch != plugin.reference && !strings.HasPrefix(revision.Commit, plugin.reference) {
+		fmt.Printf("Warning: Plugin %v is checked out at %v but pinned to %v, run -update to switch\n", plugin.name, revision.Branch, plugin.reference)
+	}
+	revision.Status = statusAdopted
+	return &Result{Plugin: plugin, Revision: revision}
+}
+
+func (tmux *Config) reportUnknown(tpmPath string) {
+	entries, err := os.ReadDir(tpmPath)
+	if err != nil {
+		fmt.Println("Unable to read TPM plugins:", err)
+		return
+	}
+	declared := make(map[string]bool, len(tmux.plugins))
+	for _, plugin := range tmux.plugins {
+		declared[plugin.directory()] = true
+	}
+	for _, entry := range entries {
+		if entry.IsDir() && entry.Name() != "tpm" && !declared[entry.Name()] {
+			fmt.Println("Warning: TPM plugin is not declared in a form teminel understands:", filepath.Join(tpmPath, entry.Name()))
+		}
+	}
+	for _, unparsed := range tmux.unparsed {
+		fmt.Printf("Warning: Declaration was not migrated %v:%v: %v\n", unparsed.file, unparsed.line, unparsed.text)
+	}
+}
+
+func (tmux *Config) migrateRunLines(removeTpm bool) error {
+	lines, err := tmux.findRunLines()
+	if err != nil {
+		return err
+	}
+	replacement := fmt.Sprintf("run-shell -b %v", shellJoin([]string{shellJoin(tmux.Command)}))
+	if len(lines) == 0 {
+		fmt.Println("No TPM run line found, load the plugins with:", replacement)
+		return nil
+	}
+	for index := len(lines) - 1; index >= 0; index-- {
+		line := lines[index]
+		if !removeTpm {
+			fmt.Printf("Replace the TPM run line at %v:%v with: %v\n", line.file, line.line, replacement)
+			continue
+		}
+		fmt.Printf("Disabling the TPM run line at %v:%v\n", line.file, line.line)
+		if err := disableLine(line, replacement); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (tmux *Config) findRunLines() ([]*tpmLine, error) {
+	var lines []*tpmLine
+	for _, file := range tmux.files {
+		content, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		scanner := bufio.NewScanner(content)
+		number := 0
+		for scanner.Scan() {
+			number++
+			if tpmRunMatcher.MatchString(scanner.Text()) {
+				lines = append(lines, &tpmLine{file: file, line: number})
+			}
+		}
+		content.Close()
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return lines, nil
+}
+
+func disableLine(line *tpmLine, replacement string) error {
+	info, err := os.Stat(line.file)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(line.file)
+	if err != nil {
+		return err
+	}
+	lines := strings.Split(string(data), "\n")
+	lines[line.line-1] = "# " + lines[line.line-1] + "\n" + replacement
+	return os.WriteFile(line.file, []byte(strings.Join(lines, "\n")), info.Mode())
+}
@@ -0,0 +1,107 @@
+package tmux
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+func (tmux *Config) requirement(plugin *Plugin) error {
+	for _, name := range plugin.requires {
+		if tmux.findPlugin(name) != nil {
+			continue
+		}
+		for _, skipped := range tmux.plugins {
+			if skipped.name == name || skipped.directory() == name {
+				return fmt.Errorf("Requires %v which is skipped: %v", name, skipped.skip)
+			}
+		}
+		return fmt.Errorf("Requires %v which is not declared", name)
+	}
+	return nil
+}
+
+func (tmux *Config) precheck(plugin *Plugin) error {
+	if err := tmux.sharedDirectory(plugin); err != nil {
+		return err
+	}
+	if err := tmux.requirement(plugin); err != nil {
+		return err
+	}
+	return tmux.trust(plugin)
+}
+
+func (tmux *Config) sharedDirectory(plugin *Plugin) error {
+	for _, other := range tmux.active() {
+		if other != plugin && other.directory() == plugin.directory() {
+			return fmt.Errorf("Shares the directory %v with %v declared at %v", plugin.directory(), other.name, other.declaration())
+		}
+	}
+	return nil
+}
+
+func (tmux *Config) ordered() ([]*Plugin, error) {
+	plugins := tmux.active()
+	if err := checkDirectories(plugins); err != nil {
+		return nil, err
+	}
+	dependents := make(map[*Plugin][]*Plugin, len(plugins))
+	pending := make(map[*Plugin]int, len(plugins))
+	for _, plugin := range plugins {
+		for _, name := range slices.Concat(plugin.after, plugin.requires) {
+			dependency := tmux.findPlugin(name)
+			if dependency == nil || dependency == plugin {
+				continue
+			}
+			dependents[dependency] = append(dependents[dependency], plugin)
+			pending[plugin]++
+		}
+	}
+	var ordered []*Plugin
+	loaded := make(map[*Plugin]bool, len(plugins))
+	for len(ordered) < len(plugins) {
+		progress := false
+		for _, plugin := range plugins {
+			if loaded[plugin] || pending[plugin] != 0 {
+				continue
+			}
+			loaded[plugin] = true
+			ordered = append(ordered, plugin)
+			for _, dependent := range dependents[plugin] {
+				pending[dependent]--
+			}
+			progress = true
+			break
+		}
+		if !progress {
+			var cycle []string
+			for _, plugin := range plugins {
+				if !loaded[plugin] {
+					cycle = append(cycle, plugin.name)
+				}
+			}
+			return nil, fmt.Errorf("Dependency cycle between plugins: %v", strings.Join(cycle, ", "))
+		}
+	}
+	return ordered, nil
+}
+
+func checkDirectories(plugins []*Plugin) error {
+	owners := make(map[string]*Plugin, len(plugins))
+	for _, plugin := range plugins {
+		if other, found := owners[plugin.directory()]; found {
+			return fmt.Errorf("Plugins %v declared at %v and %v declared at %v share the directory %v", other.name, other.declaration(), plugin.name, plugin.declaration(), plugin.directory())
+		}
+		owners[plugin.directory()] = plugin
+	}
+	return nil
+}
+
+func (tmux *Config) failedRequirement(plugin *Plugin, failed []string) error {
+	for _, name := range plugin.requires {
+		if dependency := tmux.findPlugin(name); dependency != nil && slices.Contains(failed, dependency.name) {
+			return fmt.Errorf("Required plugin %v failed to load", dependency.name)
+		}
+	}
+	return nil
+}
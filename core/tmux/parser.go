@@ -0,0 +1,206 @@
+package tmux
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type Unparsed struct {
+	file   string
+	line   int
+	text   string
+	reason string
+}
+
+type setOption struct {
+	name   string
+	value  string
+	unset  bool
+	append bool
+}
+
+func tokenize(line string) ([][]string, error) {
+	var commands [][]string
+	var command []string
+	var token strings.Builder
+	inToken, escaped, separator := false, false, false
+	quote := rune(0)
+	finishToken := func() {
+		if !inToken {
+			return
+		}
+		value := token.String()
+		if separator {
+			value = strings.TrimSuffix(value, ";")
+		}
+		if len(value) != 0 || !separator {
+			command = append(command, value)
+		}
+		if separator && len(command) != 0 {
+			commands = append(commands, command)
+			command = nil
+		}
+		token.Reset()
+		inToken, separator = false, false
+	}
+	for _, char := range line {
+		switch {
+		case escaped:
+			token.WriteRune(char)
+			escaped, separator = false, false
+		case quote == '\'':
+			if char == '\'' {
+				quote = 0
+			} else {
+				token.WriteRune(char)
+			}
+		case quote == '"':
+			if char == '\\' {
+				escaped = true
+			} else if char == '"' {
+				quote = 0
+			} else {
+				token.WriteRune(char)
+			}
+		case char == '\\':
+			escaped, inToken = true, true
+		case char == '\'' || char == '"':
+			quote, inToken, separator = char, true, false
+		case char == '#' && !inToken:
+			finishToken()
+			return appendCommand(commands, command), nil
+		case unicode.IsSpace(char):
+			finishToken()
+		default:
+			token.WriteRune(char)
+			inToken, separator = true, char == ';'
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("Unterminated quote %q", quote)
+	}
+	if escaped {
+		return nil, fmt.Errorf("Trailing escape character")
+	}
+	finishToken()
+	return appendCommand(commands, command), nil
+}
+
+func appendCommand(commands [][]string, command []string) [][]string {
+	if len(command) == 0 {
+		return commands
+	}
+	return append(commands, command)
+}
+
+func continuesLine(line string) bool {
+	trailing := len(line) - len(strings.TrimRight(line, "\\"))
+	return trailing%2 == 1
+}
+
+func parseSetOption(args []string) (*setOption, error) {
+	option := &setOption{}
+	var positional []string
+	for index := 0; index < len(args); index++ {
+		arg := args[index]
+		if len(positional) == 0 && len(arg) > 1 && strings.HasPrefix(arg, "-") {
+			flags := arg[1:]
+			for position, flag := range flags {
+				if flag == 't' {
+					if position == len(flags)-1 {
+						index++
+					}
+					break
+				}
+				switch flag {
+				case 'u':
+					option.unset = true
+				case 'a':
+					option.append = true
+				}
+			}
+			continue
+		}
+		positional = append(positional, arg)
+	}
+	switch len(positional) {
+	case 0:
+		return nil, fmt.Errorf("Missing option name")
+	case 1:
+		option.name = positional[0]
+	case 2:
+		option.name, option.value = positional[0], positional[1]
+	default:
+		return nil, fmt.Errorf("Too many arguments for option %v", positional[0])
+	}
+	return option, nil
+}
+
+func parseSourceFile(args []string) ([]string, bool) {
+	var paths []string
+	quiet := false
+	for _, arg := range args {
+		if len(paths) == 0 && len(arg) > 1 && strings.HasPrefix(arg, "-") {
+			quiet = quiet || strings.Contains(arg, "q")
+			continue
+		}
+		paths = append(paths, arg)
+	}
+	return paths, quiet
+}
+
+func skipFields(line string, count int) string {
+	fields, inField, escaped := 0, false, false
+	quote := rune(0)
+	for index, char := range line {
+		switch {
+		case escaped:
+			escaped = false
+		case quote != 0:
+			if char == quote {
+				quote = 0
+			} else if char == '\\' && quote == '"' {
+				escaped = true
+			}
+		case char == '\\':
+			escaped, inField = true, true
+		case char == '\'' || char == '"':
+			quote, inField = char, true
+		case unicode.IsSpace(char):
+			if inField {
+				inField = false
+				fields++
+				if fields == count {
+					return strings.TrimSpace(line[index:])
+				}
+			}
+		default:
+			inField = true
+		}
+	}
+	return ""
+}
+
+func bindingCommand(binding string) (string, error) {
+	commands, err := tokenize(binding)
+	if err != nil {
+		return "", err
+	}
+	if len(commands) == 0 || len(commands[0]) < 2 {
+		return "", fmt.Errorf("Unable to parse key binding: %v", binding)
+	}
+	tokens := commands[0]
+	index := 1
+	for index < len(tokens) && strings.HasPrefix(tokens[index], "-") {
+		if tokens[index] == "-T" || tokens[index] == "-N" {
+			index++
+		}
+		index++
+	}
+	command := skipFields(binding, index+1)
+	if len(command) == 0 {
+		return "", fmt.Errorf("Key binding has no command: %v", binding)
+	}
+	return command, nil
+}
@@ -0,0 +1,175 @@
+package tmux
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		line     string
+		expected [][]string
+		fails    bool
+	}{
+		{line: "", expected: nil},
+		{line: "# set -g @plugin 'a/b'", expected: nil},
+		{line: "set -g @plugin 'tmux-plugins/tmux-sensible'", expected: [][]string{{"set", "-g", "@plugin", "tmux-plugins/tmux-sensible"}}},
+		{line: `set-option -g @plugin "tmux-plugins/tmux-yank"`, expected: [][]string{{"set-option", "-g", "@plugin", "tmux-plugins/tmux-yank"}}},
+		{line: "set -g @plugin 'a/b' # trailing comment", expected: [][]string{{"set", "-g", "@plugin", "a/b"}}},
+		{line: "set -g @plugin a/b#v1.0", expected: [][]string{{"set", "-g", "@plugin", "a/b#v1.0"}}},
+		{line: "set -g @plugin 'a/b # not a comment'", expected: [][]string{{"set", "-g", "@plugin", "a/b # not a comment"}}},
+		{line: "set -g @dracula.show-powerline true", expected: [][]string{{"set", "-g", "@dracula.show-powerline", "true"}}},
+		{line: "set -g @tpm_plugins 'a/b c/d'", expected: [][]string{{"set", "-g", "@tpm_plugins", "a/b c/d"}}},
+		{line: "set -g status on; set -g @plugin a/b", expected: [][]string{{"set", "-g", "status", "on"}, {"set", "-g", "@plugin", "a/b"}}},
+		{line: "set -g status on ; set -g @plugin a/b", expected: [][]string{{"set", "-g", "status", "on"}, {"set", "-g", "@plugin", "a/b"}}},
+		{line: `bind x send-keys \;`, expected: [][]string{{"bind", "x", "send-keys", ";"}}},
+		{line: `set -g @plugin "a/\"b\""`, expected: [][]string{{"set", "-g", "@plugin", `a/"b"`}}},
+		{line: `set -g @plugin 'a\b'`, expected: [][]string{{"set", "-g", "@plugin", `a\b`}}},
+		{line: "set -g @plugin ''", expected: [][]string{{"set", "-g", "@plugin", ""}}},
+		{line: "set -g @plugin 'a/b", fails: true},
+		{line: `set -g @plugin "a/b`, fails: true},
+		{line: `set -g @plugin a/b\`, fails: true},
+	}
+	for _, test := range tests {
+		commands, err := tokenize(test.line)
+		if test.fails {
+			if err == nil {
+				t.Errorf("tokenize(%q) = %q, expected an error", test.line, commands)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("tokenize(%q) failed: %v", test.line, err)
+			continue
+		}
+		if !reflect.DeepEqual(commands, test.expected) {
+			t.Errorf("tokenize(%q) = %q, expected %q", test.line, commands, test.expected)
+		}
+	}
+}
+
+func TestContinuesLine(t *testing.T) {
+	tests := []struct {
+		line     string
+		expected bool
+	}{
+		{line: "set -g @plugin a/b", expected: false},
+		{line: `set -g @tpm_plugins ' \`, expected: true},
+		{line: `set -g @plugin a\\`, expected: false},
+		{line: `set -g @plugin a\\\`, expected: true},
+		{line: "", expected: false},
+	}
+	for _, test := range tests {
+		if continues := continuesLine(test.line); continues != test.expected {
+			t.Errorf("continuesLine(%q) = %v, expected %v", test.line, continues, test.expected)
+		}
+	}
+}
+
+func TestParseSetOption(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected *setOption
+		fails    bool
+	}{
+		{args: []string{"-g", "@plugin", "a/b"}, expected: &setOption{name: "@plugin", value: "a/b"}},
+		{args: []string{"@plugin", "a/b"}, expected: &setOption{name: "@plugin", value: "a/b"}},
+		{args: []string{"-gu", "@plugin_profile"}, expected: &setOption{name: "@plugin_profile", unset: true}},
+		{args: []string{"-ga", "@tpm_plugins", " c/d"}, expected: &setOption{name: "@tpm_plugins", value: " c/d", append: true}},
+		{args: []string{"-t", "main", "@plugin", "a/b"}, expected: &setOption{name: "@plugin", value: "a/b"}},
+		{args: []string{"-gt", "main", "@plugin", "a/b"}, expected: &setOption{name: "@plugin", value: "a/b"}},
+		{args: []string{"-tmain", "@plugin", "a/b"}, expected: &setOption{name: "@plugin", value: "a/b"}},
+		{args: []string{"-g", "@catppuccin.flavour", "mocha"}, expected: &setOption{name: "@catppuccin.flavour", value: "mocha"}},
+		{args: []string{"-g", "@plugin", "-"}, expected: &setOption{name: "@plugin", value: "-"}},
+		{args: []string{"-g", "status"}, expected: &setOption{name: "status"}},
+		{args: []string{"-g"}, fails: true},
+		{args: []string{"-g", "@plugin", "a/b", "c/d"}, fails: true},
+	}
+	for _, test := range tests {
+		option, err := parseSetOption(test.args)
+		if test.fails {
+			if err == nil {
+				t.Errorf("parseSetOption(%q) = %+v, expected an error", test.args, option)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseSetOption(%q) failed: %v", test.args, err)
+			continue
+		}
+		if *option != *test.expected {
+			t.Errorf("parseSetOption(%q) = %+v, expected %+v", test.args, option, test.expected)
+		}
+	}
+}
+
+func TestParseSourceFile(t *testing.T) {
+	tests := []struct {
+		args  []string
+		paths []string
+		quiet bool
+	}{
+		{args: []string{"~/.tmux/extra.conf"}, paths: []string{"~/.tmux/extra.conf"}},
+		{args: []string{"-q", "a.conf", "b.conf"}, paths: []string{"a.conf", "b.conf"}, quiet: true},
+		{args: []string{"-nq", "a.conf"}, paths: []string{"a.conf"}, quiet: true},
+		{args: []string{"-F", "a.conf"}, paths: []string{"a.conf"}},
+	}
+	for _, test := range tests {
+		paths, quiet := parseSourceFile(test.args)
+		if !reflect.DeepEqual(paths, test.paths) || quiet != test.quiet {
+			t.Errorf("parseSourceFile(%q) = %q, %v, expected %q, %v", test.args, paths, quiet, test.paths, test.quiet)
+		}
+	}
+}
+
+func TestSkipFields(t *testing.T) {
+	tests := []struct {
+		line     string
+		count    int
+		expected string
+	}{
+		{line: "bind -n C-a send-prefix", count: 2, expected: "C-a send-prefix"},
+		{line: "bind   -n   C-a   send-prefix  ", count: 3, expected: "send-prefix"},
+		{line: "bind 'a b' display 'x y'", count: 2, expected: "display 'x y'"},
+		{line: `bind "a \" b" display`, count: 2, expected: "display"},
+		{line: `bind a\ b display`, count: 2, expected: "display"},
+		{line: "bind a display", count: 3, expected: ""},
+	}
+	for _, test := range tests {
+		if skipped := skipFields(test.line, test.count); skipped != test.expected {
+			t.Errorf("skipFields(%q, %v) = %q, expected %q", test.line, test.count, skipped, test.expected)
+		}
+	}
+}
+
+func TestBindingCommand(t *testing.T) {
+	tests := []struct {
+		binding  string
+		expected string
+		fails    bool
+	}{
+		{binding: "bind-key -T prefix I run-shell '~/.tmux/plugins/tpm/bin/install_plugins'", expected: "run-shell '~/.tmux/plugins/tpm/bin/install_plugins'"},
+		{binding: `bind -n M-a display "hello there"`, expected: `display "hello there"`},
+		{binding: "bind -N 'Install plugins' I run-shell teminel", expected: "run-shell teminel"},
+		{binding: "bind U send-keys x \\; send-keys y", expected: "send-keys x \\; send-keys y"},
+		{binding: "bind I", fails: true},
+		{binding: "bind", fails: true},
+		{binding: "bind 'I", fails: true},
+	}
+	for _, test := range tests {
+		command, err := bindingCommand(test.binding)
+		if test.fails {
+			if err == nil {
+				t.Errorf("bindingCommand(%q) = %q, expected an error", test.binding, command)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("bindingCommand(%q) failed: %v", test.binding, err)
+			continue
+		}
+		if command != test.expected {
+			t.Errorf("bindingCommand(%q) = %q, expected %q", test.binding, command, test.expected)
+		}
+	}
+}
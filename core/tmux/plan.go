@@ -0,0 +1,72 @@
+package tmux
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"source.cyberpi.de/go/teminel/load"
+	"source.cyberpi.de/go/teminel/utils"
+)
+
+func (tmux *Config) Plan(writer io.Writer) error {
+	if _, err := tmux.ordered(); err != nil {
+		fmt.Println("Warning:", err)
+	}
+	table := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "PLUGIN\tHOST\tDECLARED\tACTION\tDETAIL")
+	for _, plugin := range tmux.plugins {
+		action, detail := tmux.plan(plugin)
+		fmt.Fprintf(table, "%v\t%v\t%v\t%v\t%v\n", plugin.name, plugin.host, plugin.declaration(), action, detail)
+	}
+	orphans, err := tmux.Orphans()
+	if err != nil {
+		return err
+	}
+	for _, orphan := range orphans {
+		fmt.Fprintf(table, "%v\t\t\tremove orphan\t%v\n", filepath.Base(orphan), orphan)
+	}
+	return table.Flush()
+}
+
+func (tmux *Config) plan(plugin *Plugin) (string, string) {
+	if len(plugin.skip) != 0 {
+		return "skip", plugin.skip
+	}
+	if err := tmux.requirement(plugin); err != nil {
+		return "fail", err.Error()
+	}
+	if err := tmux.trust(plugin); err != nil {
+		return "refuse", err.Error()
+	}
+	if len(plugin.local) != 0 {
+		target := filepath.Join(tmux.path, plugin.directory())
+		if link, err := os.Readlink(target); err == nil && link == plugin.local {
+			return load.ActionUpToDate, plugin.local
+		}
+		if utils.VerifyPath(target) {
+			return "relink", plugin.local
+		}
+		return "link", plugin.local
+	}
+	plan, err := tmux.source(plugin).Plan(plugin.name, tmux.path, tmux.reference(plugin))
+	if err != nil {
+		return "fail", err.Error()
+	}
+	switch plan.Action {
+	case load.ActionClone:
+		return fmt.Sprintf("clone via %v", plan.Protocol), fmt.Sprintf("%v at %.12v", plan.URL, plan.To)
+	case load.ActionTarball:
+		if len(plan.From) != 0 {
+			return "refresh tarball", plan.URL
+		}
+		return "tarball fallback", plan.URL
+	case load.ActionUpdate:
+		return plan.Action, fmt.Sprintf("%v %.12v..%.12v", plan.Branch, plan.From, plan.To)
+	case load.ActionReclone:
+		return plan.Action, fmt.Sprintf("switch to %v at %.12v", plan.Branch, plan.To)
+	}
+	return plan.Action, fmt.Sprintf("%v@%.12v", plan.Branch, plan.To)
+}
@@ -0,0 +1,152 @@
+package tmux
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"source.cyberpi.de/go/teminel/load"
+	"source.cyberpi.de/go/teminel/utils"
+)
+
+type Policy struct {
+	Hosts   []string          `json:"hosts"`
+	Owners  []string          `json:"owners"`
+	Commits map[string]string `json:"commits"`
+	Local   bool              `json:"local"`
+	path    string
+}
+
+func ReadPolicy(explicit string, configPath string) (*Policy, error) {
+	policyPath := configPath + ".policy.json"
+	if len(explicit) != 0 {
+		resolved, err := utils.ResolvePath(explicit)
+		if err != nil {
+			return nil, err
+		}
+		policyPath = resolved
+	} else if !utils.VerifyPath(policyPath) {
+		return nil, nil
+	}
+	data, err := os.ReadFile(policyPath)
+	if err != nil {
+		return nil, err
+	}
+	policy := &Policy{path: policyPath}
+	if err := json.Unmarshal(data, policy); err != nil {
+		return nil, fmt.Errorf("Invalid policy %v: %v", policyPath, err)
+	}
+	fmt.Println("Using trust policy:", policyPath)
+	return policy, nil
+}
+
+func (policy *Policy) check(plugin *Plugin) error {
+	if len(plugin.local) != 0 {
+		if !policy.Local {
+			return fmt.Errorf("local plugins are not trusted by policy %v", policy.path)
+		}
+		return nil
+	}
+	if !matchesPattern(plugin.host, policy.Hosts) {
+		return fmt.Errorf("host %v is not trusted by policy %v", plugin.host, policy.path)
+	}
+	owner, _, _ := strings.Cut(plugin.name, "/")
+	if !matchesPattern(owner, policy.Owners) {
+		return fmt.Errorf("owner %v is not trusted by policy %v", owner, policy.path)
+	}
+	required := policy.Commits[plugin.name]
+	if len(required) != 0 && len(plugin.reference) != 0 && !strings.HasPrefix(required, plugin.reference) {
+		return fmt.Errorf("pinned to %v but policy %v requires commit %v", plugin.reference, policy.path, required)
+	}
+	return nil
+}
+
+func matchesPattern(value string, patterns []string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, value); matched {
+			return true
+		}
+	}
+	return false
+}
+
+func (tmux *Config) trust(plugin *Plugin) error {
+	if tmux.Policy == nil {
+		return nil
+	}
+	err := tmux.Policy.check(plugin)
+	if err == nil {
+		return nil
+	}
+	if tmux.AllowUntrusted {
+		fmt.Printf("Warning: Allowing untrusted plugin %v: %v\n", plugin.name, err)
+		return nil
+	}
+	return fmt.Errorf("Refusing plugin %v: %v", plugin.name, err)
+}
+
+func (tmux *Config) requiredCommit(plugin *Plugin) string {
+	if tmux.Policy == nil || len(plugin.local) != 0 {
+		return ""
+	}
+	return tmux.Policy.Commits[plugin.name]
+}
+
+func (tmux *Config) reference(plugin *Plugin) string {
+	if required := tmux.requiredCommit(plugin); len(required) != 0 {
+		return required
+	}
+	return plugin.reference
+}
+
+func (tmux *Config) trustScripts(plugin *Plugin) error {
+	if err := tmux.trust(plugin); err != nil {
+		return err
+	}
+	required := tmux.requiredCommit(plugin)
+	if len(required) == 0 {
+		return nil
+	}
+	reason, err := tmux.verifyCommit(plugin, required)
+	if err != nil {
+		reason = fmt.Sprintf("unable to verify required commit %v: %v", required, err)
+	} else if len(reason) == 0 {
+		return nil
+	}
+	if tmux.AllowUntrusted {
+		fmt.Printf("Warning: Allowing untrusted scripts of plugin %v: %v\n", plugin.name, reason)
+		return nil
+	}
+	return fmt.Errorf("Refusing scripts of plugin %v: %v", plugin.name, reason)
+}
+
+func (tmux *Config) verifyCommit(plugin *Plugin, required string) (string, error) {
+	revision, err := load.Inspect(filepath.Join(tmux.path, plugin.directory()))
+	if err != nil {
+		return "", err
+	}
+	if revision.Method != load.MethodTarball {
+		if strings.HasPrefix(revision.Commit, required) {
+			return "", nil
+		}
+		return fmt.Sprintf("checked out at %.12v but policy %v requires %v", revision.Commit, tmux.Policy.path, required), nil
+	}
+	lock, err := ReadLock(tmux.lockPath)
+	if err != nil {
+		return "", err
+	}
+	entry := lock.Find(plugin.host, plugin.name)
+	if entry == nil || len(entry.Tarball) == 0 {
+		return "", fmt.Errorf("tarball of plugin is not part of the lockfile %v", tmux.lockPath)
+	}
+	if strings.HasSuffix(entry.Tarball, "/"+required+".tar.gz") {
+		return "", nil
+	}
+	return fmt.Sprintf("tarball %v does not match commit %v required by policy %v", entry.Tarball, required, tmux.Policy.path), nil
+}
@@ -0,0 +1,52 @@
+package tmux
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var profileMatcher = regexp.MustCompile(`^[\w.-]+$`)
+
+func CheckProfile(profile string) error {
+	if len(profile) != 0 && !profileMatcher.MatchString(profile) {
+		return fmt.Errorf("Invalid profile name: %v", profile)
+	}
+	return nil
+}
+
+func (tmux *Config) selectProfile(configPath string) error {
+	if len(tmux.Profile) == 0 {
+		return nil
+	}
+	if err := CheckProfile(tmux.Profile); err != nil {
+		return err
+	}
+	declared := false
+	for _, plugin := range tmux.plugins {
+		declared = declared || slices.Contains(plugin.profiles, tmux.Profile)
+	}
+	if !declared {
+		fmt.Println("Warning: Profile is not declared in the config:", tmux.Profile)
+	}
+	tmux.path = tmux.path + "-" + tmux.Profile
+	tmux.lockPath = fmt.Sprintf("%v.%v.lock", configPath, tmux.Profile)
+	fmt.Println("Using plugin profile:", tmux.Profile, "with plugin path:", tmux.path)
+	return nil
+}
+
+func (tmux *Config) profileReason(plugin *Plugin) string {
+	if slices.Contains(plugin.profiles, tmux.Profile) {
+		return ""
+	}
+	if len(plugin.profiles) != 0 {
+		return fmt.Sprintf("only in profile %v", strings.Join(plugin.profiles, ", "))
+	}
+	for _, other := range tmux.plugins {
+		if other.directory() == plugin.directory() && slices.Contains(other.profiles, tmux.Profile) {
+			return fmt.Sprintf("overridden in profile %v at %v", tmux.Profile, other.declaration())
+		}
+	}
+	return ""
+}
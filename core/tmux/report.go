@@ -0,0 +1,66 @@
+package tmux
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"source.cyberpi.de/go/teminel/load"
+)
+
+type Result struct {
+	Plugin   *Plugin
+	Revision *load.Revision
+	Err      error
+}
+
+func (result *Result) status() string {
+	if result.Err != nil {
+		return "failed"
+	}
+	return result.Revision.Status
+}
+
+func (result *Result) method() string {
+	if result.Revision == nil {
+		return "-"
+	}
+	return result.Revision.Method
+}
+
+func (result *Result) detail() string {
+	if result.Err != nil {
+		return result.Err.Error()
+	}
+	if len(result.Plugin.local) != 0 {
+		return result.Plugin.local
+	}
+	if len(result.Revision.Tarball) != 0 {
+		return result.Revision.Tarball
+	}
+	return fmt.Sprintf("%v@%.12v", result.Revision.Branch, result.Revision.Commit)
+}
+
+func Report(results []*Result) error {
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "PLUGIN\tHOST\tDECLARED\tMETHOD\tSTATUS\tDETAIL")
+	failed := 0
+	for _, result := range results {
+		if result.Err != nil {
+			failed++
+		}
+		fmt.Fprintf(writer, "%v\t%v\t%v\t%v\t%v\t%v\n",
+			result.Plugin.name,
+			result.Plugin.host,
+			result.Plugin.declaration(),
+			result.method(),
+			result.status(),
+			result.detail(),
+		)
+	}
+	writer.Flush()
+	if failed != 0 {
+		return fmt.Errorf("%v of %v plugins failed", failed, len(results))
+	}
+	return nil
+}
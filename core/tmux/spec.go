@@ -0,0 +1,74 @@
+package tmux
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"source.cyberpi.de/go/teminel/utils"
+)
+
+var (
+	shortSpecMatcher = regexp.MustCompile(`^[\w.-]+(/[\w.-]+)+$`)
+	scpSpecMatcher   = regexp.MustCompile(`^([\w.-]+)@([\w.-]+):([\w./-]+)$`)
+)
+
+func parseSpec(spec string, host string, from string) (*Plugin, error) {
+	location, reference, _ := strings.Cut(spec, "#")
+	plugin := &Plugin{reference: reference}
+	if local, found := localSpecPath(location); found {
+		if len(reference) != 0 {
+			return nil, fmt.Errorf("Local plugins can not be pinned: %v", spec)
+		}
+		resolved, err := utils.ResolvePath(local)
+		if err != nil {
+			return nil, err
+		}
+		if !filepath.IsAbs(resolved) {
+			resolved = filepath.Join(filepath.Dir(from), resolved)
+		}
+		plugin.name = resolved
+		plugin.local = resolved
+		return plugin, nil
+	}
+	if match := scpSpecMatcher.FindStringSubmatch(location); match != nil {
+		plugin.host = match[2]
+		plugin.name = trimGitSuffix(match[3])
+		plugin.url = location
+		return plugin, nil
+	}
+	if strings.Contains(location, "://") {
+		parsed, err := url.Parse(location)
+		if err != nil {
+			return nil, err
+		}
+		name := trimGitSuffix(strings.Trim(parsed.Path, "/"))
+		if len(parsed.Host) == 0 || !shortSpecMatcher.MatchString(name) {
+			return nil, fmt.Errorf("Unsupported plugin url: %v", location)
+		}
+		plugin.host = parsed.Host
+		plugin.name = name
+		plugin.url = location
+		return plugin, nil
+	}
+	if !shortSpecMatcher.MatchString(location) {
+		return nil, fmt.Errorf("Unsupported plugin name: %v", location)
+	}
+	plugin.host = host
+	plugin.name = trimGitSuffix(location)
+	return plugin, nil
+}
+
+func localSpecPath(location string) (string, bool) {
+	if strings.HasPrefix(location, "file://") {
+		return strings.TrimPrefix(location, "file://"), true
+	}
+	relative := strings.HasPrefix(location, "./") || strings.HasPrefix(location, "../")
+	return location, relative || strings.HasPrefix(location, "~") || filepath.IsAbs(location)
+}
+
+func trimGitSuffix(name string) string {
+	return strings.TrimSuffix(name, ".git")
+}
@@ -0,0 +1,94 @@
+package tmux
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+type specResult struct {
+	host      string
+	name      string
+	reference string
+	url       string
+	local     string
+}
+
+func TestParseSpec(t *testing.T) {
+	tests := []struct {
+		spec     string
+		expected specResult
+		fails    bool
+	}{
+		{spec: "tmux-plugins/tmux-sensible", expected: specResult{host: "github.com", name: "tmux-plugins/tmux-sensible"}},
+		{spec: "tmux-plugins/tmux-sensible#v3.0.0", expected: specResult{host: "github.com", name: "tmux-plugins/tmux-sensible", reference: "v3.0.0"}},
+		{spec: "aserowy/tmux.nvim", expected: specResult{host: "github.com", name: "aserowy/tmux.nvim"}},
+		{spec: "catppuccin/tmux.git", expected: specResult{host: "github.com", name: "catppuccin/tmux"}},
+		{spec: "group/sub/plugin", expected: specResult{host: "github.com", name: "group/sub/plugin"}},
+		{spec: "git@gitlab.com:owner/plugin.git", expected: specResult{host: "gitlab.com", name: "owner/plugin", url: "git@gitlab.com:owner/plugin.git"}},
+		{spec: "git@gitlab.com:owner/plugin#main", expected: specResult{host: "gitlab.com", name: "owner/plugin", reference: "main", url: "git@gitlab.com:owner/plugin"}},
+		{spec: "https://gitlab.com/owner/plugin.git", expected: specResult{host: "gitlab.com", name: "owner/plugin", url: "https://gitlab.com/owner/plugin.git"}},
+		{spec: "https://gitlab.com/group/sub/plugin#v1", expected: specResult{host: "gitlab.com", name: "group/sub/plugin", reference: "v1", url: "https://gitlab.com/group/sub/plugin"}},
+		{spec: "ssh://git@example.org:2222/owner/plugin", expected: specResult{host: "example.org:2222", name: "owner/plugin", url: "ssh://git@example.org:2222/owner/plugin"}},
+		{spec: "tmux-sensible", fails: true},
+		{spec: "owner/", fails: true},
+		{spec: "https://gitlab.com/", fails: true},
+		{spec: "https:///owner/plugin", fails: true},
+		{spec: "owner/plugin name", fails: true},
+	}
+	for _, test := range tests {
+		plugin, err := parseSpec(test.spec, "github.com", "/etc/tmux/tmux.conf")
+		if test.fails {
+			if err == nil {
+				t.Errorf("parseSpec(%q) = %+v, expected an error", test.spec, plugin)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseSpec(%q) failed: %v", test.spec, err)
+			continue
+		}
+		result := specResult{
+			host:      plugin.host,
+			name:      plugin.name,
+			reference: plugin.reference,
+			url:       plugin.url,
+			local:     plugin.local,
+		}
+		if result != test.expected {
+			t.Errorf("parseSpec(%q) = %+v, expected %+v", test.spec, result, test.expected)
+		}
+	}
+}
+
+func TestParseLocalSpec(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	tests := []struct {
+		spec     string
+		expected string
+		fails    bool
+	}{
+		{spec: "/opt/plugins/mine", expected: "/opt/plugins/mine"},
+		{spec: "file:///opt/plugins/mine", expected: "/opt/plugins/mine"},
+		{spec: "~/src/mine", expected: filepath.Join(home, "src/mine")},
+		{spec: "./mine", expected: "/etc/tmux/mine"},
+		{spec: "../src/mine", expected: "/etc/src/mine"},
+		{spec: "/opt/plugins/mine#main", fails: true},
+	}
+	for _, test := range tests {
+		plugin, err := parseSpec(test.spec, "github.com", "/etc/tmux/tmux.conf")
+		if test.fails {
+			if err == nil {
+				t.Errorf("parseSpec(%q) = %+v, expected an error", test.spec, plugin)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseSpec(%q) failed: %v", test.spec, err)
+			continue
+		}
+		if plugin.local != test.expected || plugin.name != test.expected || len(plugin.host) != 0 {
+			t.Errorf("parseSpec(%q) = local %q, name %q, host %q, expected local %q", test.spec, plugin.local, plugin.name, plugin.host, test.expected)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package tmux
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"text/tabwriter"
+	"time"
+)
+
+type Timing struct {
+	Plugin       string        `json:"plugin"`
+	Script       string        `json:"script"`
+	Duration     time.Duration `json:"-"`
+	Milliseconds float64       `json:"milliseconds"`
+	Failed       bool          `json:"failed,omitempty"`
+}
+
+type timingReport struct {
+	Total   float64   `json:"total_milliseconds"`
+	Scripts []*Timing `json:"scripts"`
+}
+
+func (tmux *Config) record(plugin *Plugin, script string, started time.Time, err error) {
+	duration := time.Since(started)
+	tmux.timings = append(tmux.timings, &Timing{
+		Plugin:       plugin.name,
+		Script:       script,
+		Duration:     duration,
+		Milliseconds: float64(duration.Microseconds()) / 1000,
+		Failed:       err != nil,
+	})
+}
+
+func (tmux *Config) sortedTimings() ([]*Timing, time.Duration) {
+	timings := slices.Clone(tmux.timings)
+	slices.SortStableFunc(timings, func(left *Timing, right *Timing) int {
+		return int(right.Duration - left.Duration)
+	})
+	var total time.Duration
+	for _, timing := range timings {
+		total += timing.Duration
+	}
+	return timings, total
+}
+
+func (tmux *Config) PrintTimings(writer io.Writer) error {
+	timings, total := tmux.sortedTimings()
+	table := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "DURATION\tSHARE\tPLUGIN\tSCRIPT\tSTATE")
+	for _, timing := range timings {
+		share := 0.0
+		if total != 0 {
+			share = float64(timing.Duration) / float64(total) * 100
+		}
+		state := "ok"
+		if timing.Failed {
+			state = "failed"
+		}
+		fmt.Fprintf(table, "%v\t%.1f%%\t%v\t%v\t%v\n", timing.Duration.Round(time.Millisecond), share, timing.Plugin, timing.Script, state)
+	}
+	fmt.Fprintf(table, "%v\t\ttotal\t%v scripts\t\n", total.Round(time.Millisecond), len(timings))
+	return table.Flush()
+}
+
+func (tmux *Config) WriteTimings(path string) error {
+	timings, total := tmux.sortedTimings()
+	data, err := json.MarshalIndent(&timingReport{
+		Total:   float64(total.Microseconds()) / 1000,
+		Scripts: timings,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println("Writing timings:", path)
+	return os.WriteFile(path, append(data, '\n'), os.FileMode(0644))
+}
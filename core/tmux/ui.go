@@ -0,0 +1,193 @@
+package tmux
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"source.cyberpi.de/go/teminel/exec"
+	"source.cyberpi.de/go/teminel/load"
+	"source.cyberpi.de/go/teminel/utils"
+)
+
+const uiHelp = "j/k move  i install  u update  x remove  d disable  q quit"
+
+func (tmux *Config) UI() error {
+	if len(tmux.plugins) == 0 {
+		return fmt.Errorf("No plugins declared in the config")
+	}
+	terminal, err := exec.RawTerminal()
+	if err != nil {
+		return fmt.Errorf("Unable to switch terminal to raw mode: %v", err)
+	}
+	defer terminal.Restore()
+	cursor, message := 0, ""
+	for {
+		if err := tmux.render(cursor, message); err != nil {
+			return err
+		}
+		keys, err := readKeys()
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			var quit bool
+			message, quit, err = tmux.handleKey(terminal, key, &cursor)
+			if quit {
+				fmt.Print("\033[H\033[2J")
+				return nil
+			}
+			if err != nil {
+				message = "Error: " + err.Error()
+			}
+		}
+	}
+}
+
+func (tmux *Config) handleKey(terminal *exec.Terminal, key string, cursor *int) (string, bool, error) {
+	plugin := tmux.plugins[*cursor]
+	switch key {
+	case "q", "\x03", "\x1b":
+		return "", true, nil
+	case "j", "down":
+		*cursor = min(*cursor+1, len(tmux.plugins)-1)
+	case "k", "up":
+		*cursor = max(*cursor-1, 0)
+	case "i", "u":
+		message, err := tmux.runAction(terminal, plugin)
+		return message, false, err
+	case "x":
+		message, err := tmux.remove(plugin)
+		return message, false, err
+	case "d":
+		if err := tmux.toggleDisabled(plugin); err != nil {
+			return "", false, err
+		}
+		if tmux.disabled[plugin.name] {
+			return fmt.Sprintf("%v is now disabled", plugin.name), false, nil
+		}
+		return fmt.Sprintf("%v is now enabled", plugin.name), false, nil
+	}
+	return "", false, nil
+}
+
+func (tmux *Config) render(cursor int, message string) error {
+	lock, err := ReadLock(tmux.lockPath)
+	if err != nil {
+		return err
+	}
+	lines := []string{"teminel plugins in " + tmux.path, uiHelp, ""}
+	for index, plugin := range tmux.plugins {
+		entry, err := tmux.inspect(plugin, lock)
+		if err != nil {
+			return err
+		}
+		marker := "  "
+		if index == cursor {
+			marker = "\033[7m> "
+		}
+		lines = append(lines, fmt.Sprintf("%v%-28v %-28v %v\033[0m", marker, plugin.name, entry.revision(), entry.state()))
+	}
+	lines = append(lines, "", message)
+	fmt.Print("\033[H\033[2J" + strings.Join(lines, "\r\n"))
+	return nil
+}
+
+func (tmux *Config) runAction(terminal *exec.Terminal, plugin *Plugin) (string, error) {
+	if len(plugin.skip) != 0 {
+		return fmt.Sprintf("%v is skipped: %v", plugin.name, plugin.skip), nil
+	}
+	if err := terminal.Restore(); err != nil {
+		return "", err
+	}
+	fmt.Print("\033[H\033[2J")
+	result := tmux.installPlugin(plugin)
+	fmt.Print("\nPress any key to return")
+	if err := terminal.Raw(); err != nil {
+		return "", err
+	}
+	if _, err := readKeys(); err != nil {
+		return "", err
+	}
+	if result.Err != nil {
+		return "", result.Err
+	}
+	return fmt.Sprintf("%v %v", plugin.name, result.status()), nil
+}
+
+func (tmux *Config) installPlugin(plugin *Plugin) *Result {
+	if err := utils.EnsureDirectory(tmux.path); err != nil {
+		return &Result{Plugin: plugin, Err: err}
+	}
+	results := tmux.installAll([]*Plugin{plugin}, tmux.ensure)
+	result := results[0]
+	if result.Err == nil && result.Revision.Status == load.StatusUpdated {
+		printChangelog(result)
+	}
+	if err := tmux.writeLock(results); err != nil {
+		return &Result{Plugin: plugin, Err: err}
+	}
+	Report(results)
+	if result.Err == nil && tmux.Server != nil {
+		fmt.Println("Start plugin:", plugin.name, "declared at:", plugin.declaration())
+		if err := tmux.start(plugin); err != nil {
+			fmt.Println("Plugin failed to load:", plugin.name, err)
+		}
+	}
+	return result
+}
+
+func (tmux *Config) remove(plugin *Plugin) (string, error) {
+	target := filepath.Join(tmux.path, plugin.directory())
+	if !utils.VerifyPath(target) {
+		return fmt.Sprintf("%v is not installed", plugin.name), nil
+	}
+	fmt.Printf("\r\nRemove %v? [y/N]", target)
+	keys, err := readKeys()
+	if err != nil || len(keys) == 0 || keys[0] != "y" {
+		return "Nothing removed", err
+	}
+	remove := os.RemoveAll
+	if len(plugin.local) != 0 {
+		remove = removeLocal
+	}
+	if err := remove(target); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Removed %v, it stays loaded until tmux restarts", plugin.name), tmux.forget(plugin)
+}
+
+func (tmux *Config) forget(plugin *Plugin) error {
+	lock, err := ReadLock(tmux.lockPath)
+	if err != nil {
+		return err
+	}
+	var entries []*LockEntry
+	for _, entry := range lock.Plugins {
+		if entry.Host != plugin.host || entry.Name != plugin.name {
+			entries = append(entries, entry)
+		}
+	}
+	lock.Plugins = entries
+	return lock.Write(tmux.lockPath)
+}
+
+func readKeys() ([]string, error) {
+	buffer := make([]byte, 8)
+	count, err := os.Stdin.Read(buffer)
+	if err != nil {
+		return nil, err
+	}
+	input := string(buffer[:count])
+	switch input {
+	case "\x1b[A":
+		return []string{"up"}, nil
+	case "\x1b[B":
+		return []string{"down"}, nil
+	}
+	if strings.HasPrefix(input, "\x1b[") {
+		return nil, nil
+	}
+	return strings.Split(input, ""), nil
+}
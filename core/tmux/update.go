@@ -0,0 +1,64 @@
+package tmux
+
+import (
+	"fmt"
+
+	"source.cyberpi.de/go/teminel/load"
+	"source.cyberpi.de/go/teminel/utils"
+)
+
+func (tmux *Config) Update(names ...string) error {
+	plugins, err := tmux.selectPlugins(names)
+	if err != nil {
+		return err
+	}
+	if err := utils.EnsureDirectory(tmux.path); err != nil {
+		return err
+	}
+	results := tmux.installAll(plugins, tmux.ensure)
+	for _, result := range results {
+		if result.Err == nil && result.Revision.Status == load.StatusUpdated {
+			printChangelog(result)
+		}
+	}
+	if err := tmux.writeLock(results); err != nil {
+		return err
+	}
+	return Report(results)
+}
+
+func (tmux *Config) selectPlugins(names []string) ([]*Plugin, error) {
+	if len(names) == 0 {
+		return tmux.active(), nil
+	}
+	var plugins []*Plugin
+	for _, name := range names {
+		plugin := tmux.findPlugin(name)
+		if plugin == nil {
+			return nil, fmt.Errorf("Plugin is not declared in the config: %v", name)
+		}
+		plugins = append(plugins, plugin)
+	}
+	return plugins, nil
+}
+
+func (tmux *Config) findPlugin(name string) *Plugin {
+	for _, plugin := range tmux.active() {
+		if plugin.name == name || plugin.directory() == name {
+			return plugin
+		}
+	}
+	return nil
+}
+
+func printChangelog(result *Result) {
+	revision := result.Revision
+	if len(revision.Tarball) != 0 {
+		fmt.Println("Changed files of plugin:", result.Plugin.name, "from:", revision.Tarball)
+	} else {
+		fmt.Printf("Changes of plugin: %v %.7v..%.7v\n", result.Plugin.name, revision.Previous, revision.Commit)
+	}
+	for _, change := range revision.Changes {
+		fmt.Println("  ", change)
+	}
+}
@@ -0,0 +1,149 @@
+package tmux
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"syscall"
+	"time"
+
+	"source.cyberpi.de/go/teminel/utils"
+)
+
+type fileState struct {
+	modified time.Time
+	size     int64
+}
+
+func (tmux *Config) Watch(configPath string, interval time.Duration, load bool) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	current := tmux
+	states := current.snapshot()
+	fmt.Println("Watching", len(states), "config files every", interval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			fmt.Println("Stopped watching config files")
+			return nil
+		case <-ticker.C:
+		}
+		if !current.changed(states) {
+			continue
+		}
+		fmt.Println("Config changed, reading:", configPath)
+		next := current.fresh()
+		if err := next.Read(configPath); err != nil {
+			fmt.Println("Error:", err)
+			states = current.snapshot()
+			continue
+		}
+		if err := next.applyChanges(current, load); err != nil {
+			fmt.Println("Error:", err)
+		}
+		current = next
+		states = current.snapshot()
+	}
+}
+
+func (tmux *Config) fresh() *Config {
+	return &Config{
+		Source:         tmux.Source,
+		Jobs:           tmux.Jobs,
+		Command:        tmux.Command,
+		Notify:         tmux.Notify,
+		Timeout:        tmux.Timeout,
+		HookTimeout:    tmux.HookTimeout,
+		Server:         tmux.Server,
+		Policy:         tmux.Policy,
+		AllowUntrusted: tmux.AllowUntrusted,
+		Profile:        tmux.Profile,
+	}
+}
+
+func (tmux *Config) snapshot() map[string]*fileState {
+	files := slices.Clone(tmux.files)
+	for _, pattern := range tmux.patterns {
+		matches, _ := filepath.Glob(pattern)
+		files = append(files, matches...)
+	}
+	states := make(map[string]*fileState, len(files))
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			states[file] = &fileState{}
+			continue
+		}
+		states[file] = &fileState{modified: info.ModTime(), size: info.Size()}
+	}
+	return states
+}
+
+func (tmux *Config) changed(states map[string]*fileState) bool {
+	for file, current := range tmux.snapshot() {
+		state, found := states[file]
+		if !found || !current.modified.Equal(state.modified) || current.size != state.size {
+			return true
+		}
+	}
+	return false
+}
+
+func (tmux *Config) applyChanges(previous *Config, load bool) error {
+	known := make(map[string]bool)
+	if previous.path == tmux.path {
+		for _, plugin := range previous.active() {
+			known[plugin.key()] = true
+		}
+	} else {
+		fmt.Println("Plugin path changed to:", tmux.path)
+	}
+	var added []*Plugin
+	current := make(map[string]bool)
+	for _, plugin := range tmux.active() {
+		current[plugin.key()] = true
+		if !known[plugin.key()] {
+			added = append(added, plugin)
+		}
+	}
+	for _, plugin := range previous.active() {
+		if !current[plugin.key()] {
+			fmt.Println("Plugin no longer declared, it stays loaded until tmux restarts:", plugin.name)
+		}
+	}
+	if len(added) == 0 {
+		fmt.Println("No new plugins declared")
+		return nil
+	}
+	if err := utils.EnsureDirectory(tmux.path); err != nil {
+		return err
+	}
+	results := tmux.installAll(added, tmux.ensure)
+	if err := tmux.writeLock(results); err != nil {
+		return err
+	}
+	err := Report(results)
+	if !load {
+		return err
+	}
+	for _, result := range results {
+		if result.Err != nil {
+			continue
+		}
+		fmt.Println("Start plugin:", result.Plugin.name, "declared at:", result.Plugin.declaration())
+		if err := tmux.start(result.Plugin); err != nil {
+			fmt.Println("Plugin failed to load:", result.Plugin.name, err)
+		}
+	}
+	tmux.notify("loaded %v new plugins", len(added))
+	return err
+}
+
+func (plugin *Plugin) key() string {
+	return plugin.host + "\x00" + plugin.name + "\x00" + plugin.reference + "\x00" + plugin.local
+}
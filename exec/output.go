@@ -0,0 +1,55 @@
+package exec
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+)
+
+type lineWriter struct {
+	prefix string
+	output io.Writer
+	status *Status
+	buffer []byte
+}
+
+var outputMutex sync.Mutex
+
+func newLineWriter(prefix string, output io.Writer, status *Status) *lineWriter {
+	return &lineWriter{
+		prefix: prefix,
+		output: output,
+		status: status,
+	}
+}
+
+func (writer *lineWriter) Write(data []byte) (int, error) {
+	writer.buffer = append(writer.buffer, data...)
+	for {
+		index := bytes.IndexByte(writer.buffer, '\n')
+		if index < 0 {
+			return len(data), nil
+		}
+		writer.emit(string(writer.buffer[:index]))
+		writer.buffer = writer.buffer[index+1:]
+	}
+}
+
+func (writer *lineWriter) Flush() {
+	if len(writer.buffer) != 0 {
+		writer.emit(string(writer.buffer))
+		writer.buffer = nil
+	}
+}
+
+func (writer *lineWriter) emit(line string) {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+	writer.status.Output = append(writer.status.Output, line)
+	if len(writer.prefix) == 0 {
+		fmt.Fprintln(writer.output, line)
+	} else {
+		fmt.Fprintln(writer.output, writer.prefix, line)
+	}
+}
@@ -1,20 +1,60 @@
 package exec
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"os"
 	"os/exec"
+	"time"
 
 	"source.cyberpi.de/go/teminel/utils"
 )
 
-func Shell(args ...string) error {
-	stdout, err := exec.Command("sh", append([]string{"-c"}, args...)...).Output()
-	if err != nil {
-		return err
+type Script struct {
+	Prefix  string
+	Timeout time.Duration
+	Dir     string
+	Env     []string
+}
+
+type Status struct {
+	Code   int
+	Output []string
+}
+
+func (script *Script) Run(args ...string) (*Status, error) {
+	ctx := context.Background()
+	if script.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, script.Timeout)
+		defer cancel()
 	}
+	command := exec.CommandContext(ctx, "sh", append([]string{"-c"}, args...)...)
+	command.Dir = script.Dir
+	if len(script.Env) != 0 {
+		command.Env = append(os.Environ(), script.Env...)
+	}
+	command.WaitDelay = time.Second
+	status := &Status{}
+	stdout := newLineWriter(script.Prefix, os.Stdout, status)
+	stderr := newLineWriter(script.Prefix, os.Stderr, status)
+	command.Stdout = stdout
+	command.Stderr = stderr
 	fmt.Println(utils.StringsToAny(append([]string{"Run:"}, args...)...)...)
-	for _, line := range stdout {
-		fmt.Println(line)
+	err := command.Run()
+	stdout.Flush()
+	stderr.Flush()
+	status.Code = command.ProcessState.ExitCode()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return status, fmt.Errorf("Timeout after %v", script.Timeout)
+	}
+	if errors.Is(err, exec.ErrWaitDelay) && status.Code == 0 {
+		return status, nil
+	}
+	var exitError *exec.ExitError
+	if errors.As(err, &exitError) {
+		return status, fmt.Errorf("Exit status %v", status.Code)
 	}
-	return nil
+	return status, err
 }
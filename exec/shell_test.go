@@ -0,0 +1,42 @@
+package exec
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestScriptRun(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		timeout time.Duration
+		code    int
+		output  []string
+		fails   bool
+	}{
+		{name: "success", command: "echo hi; echo err >&2", output: []string{"hi", "err"}},
+		{name: "exit status", command: "echo hi; exit 3", code: 3, output: []string{"hi"}, fails: true},
+		{name: "background process", command: "echo hi; sleep 3 &", output: []string{"hi"}},
+		{name: "daemon keeping output open", command: "echo hi; (sleep 3; echo late) &", output: []string{"hi"}},
+		{name: "timeout", command: "echo hi; sleep 3", timeout: 200 * time.Millisecond, output: []string{"hi"}, fails: true},
+	}
+	for _, test := range tests {
+		script := &Script{Prefix: "[test]", Timeout: test.timeout}
+		status, err := script.Run(test.command)
+		if test.fails != (err != nil) {
+			t.Errorf("%v: Run(%q) returned error %v", test.name, test.command, err)
+		}
+		if test.timeout == 0 && status.Code != test.code {
+			t.Errorf("%v: Run(%q) exited with %v, expected %v", test.name, test.command, status.Code, test.code)
+		}
+		output := slices.Clone(status.Output)
+		slices.Sort(output)
+		expected := slices.Clone(test.output)
+		slices.Sort(expected)
+		if !slices.Equal(output, expected) {
+			t.Errorf("%v: Run(%q) printed %q, expected %q", test.name, test.command, strings.Join(status.Output, "|"), strings.Join(test.output, "|"))
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package exec
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+)
+
+type Terminal struct {
+	state string
+}
+
+func RawTerminal() (*Terminal, error) {
+	state, err := stty("-g")
+	if err != nil {
+		return nil, err
+	}
+	terminal := &Terminal{state: strings.TrimSpace(state)}
+	return terminal, terminal.Raw()
+}
+
+func (terminal *Terminal) Raw() error {
+	_, err := stty("raw", "-echo")
+	return err
+}
+
+func (terminal *Terminal) Restore() error {
+	_, err := stty(terminal.state)
+	return err
+}
+
+func stty(args ...string) (string, error) {
+	command := exec.Command("stty", args...)
+	command.Stdin = os.Stdin
+	output, err := command.Output()
+	return string(output), err
+}
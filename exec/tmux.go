@@ -0,0 +1,94 @@
+package exec
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+type Server struct {
+	Socket  string
+	Pid     string
+	Session string
+}
+
+func Tmux(args ...string) (string, error) {
+	return (*Server)(nil).Tmux(args...)
+}
+
+func (server *Server) Tmux(args ...string) (string, error) {
+	command := args
+	if server != nil && len(server.Socket) != 0 {
+		command = append([]string{"-S", server.Socket}, args...)
+	}
+	output, err := exec.Command("tmux", command...).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("Error on tmux %v: %v: %s", args[0], err, bytes.TrimSpace(output))
+	}
+	return string(output), nil
+}
+
+func ParseServer(value string) (*Server, error) {
+	fields := strings.Split(value, ",")
+	if len(fields) != 3 || len(fields[0]) == 0 {
+		return nil, fmt.Errorf("Invalid TMUX value: %v", value)
+	}
+	return &Server{Socket: fields[0], Pid: fields[1], Session: fields[2]}, nil
+}
+
+func SocketDirectory() string {
+	base := os.Getenv("TMUX_TMPDIR")
+	if len(base) == 0 {
+		base = "/tmp"
+	}
+	return filepath.Join(base, fmt.Sprintf("tmux-%v", os.Getuid()))
+}
+
+func NamedServer(name string) *Server {
+	return &Server{Socket: filepath.Join(SocketDirectory(), name)}
+}
+
+func Servers() ([]*Server, error) {
+	entries, err := os.ReadDir(SocketDirectory())
+	if err != nil {
+		return nil, err
+	}
+	var servers []*Server
+	for _, entry := range entries {
+		if entry.Type()&fs.ModeSocket == 0 {
+			continue
+		}
+		server := NamedServer(entry.Name())
+		if err := server.Resolve(); err != nil {
+			fmt.Println("Skipping tmux server:", server.Socket, err)
+			continue
+		}
+		servers = append(servers, server)
+	}
+	return servers, nil
+}
+
+func (server *Server) Resolve() error {
+	if len(server.Pid) != 0 {
+		return nil
+	}
+	output, err := server.Tmux("display-message", "-p", "#{pid},#{session_id}")
+	if err != nil {
+		return err
+	}
+	pid, session, _ := strings.Cut(strings.TrimSpace(output), ",")
+	server.Pid = pid
+	server.Session = strings.TrimPrefix(session, "$")
+	return nil
+}
+
+func (server *Server) Env() []string {
+	if server == nil {
+		return nil
+	}
+	return []string{fmt.Sprintf("TMUX=%v,%v,%v", server.Socket, server.Pid, server.Session)}
+}
@@ -0,0 +1,74 @@
+package load
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/utils/merkletrie"
+)
+
+const changelogLimit = 100
+
+func commitSubjects(repository *git.Repository, from plumbing.Hash, to plumbing.Hash) []string {
+	commits, err := repository.Log(&git.LogOptions{From: to})
+	if err != nil {
+		return []string{fmt.Sprintf("Unable to read commits: %v", err)}
+	}
+	defer commits.Close()
+	var subjects []string
+	for len(subjects) < changelogLimit {
+		commit, err := commits.Next()
+		if err != nil || commit.Hash == from {
+			break
+		}
+		subject, _, _ := strings.Cut(commit.Message, "\n")
+		subjects = append(subjects, fmt.Sprintf("%.7v %v", commit.Hash.String(), subject))
+	}
+	return subjects
+}
+
+func fileChanges(repository *git.Repository, from plumbing.Hash) []string {
+	head, err := repository.Head()
+	if err != nil {
+		return []string{fmt.Sprintf("Unable to read head: %v", err)}
+	}
+	before, err := commitTree(repository, from)
+	if err != nil {
+		return []string{fmt.Sprintf("Unable to read previous tree: %v", err)}
+	}
+	after, err := commitTree(repository, head.Hash())
+	if err != nil {
+		return []string{fmt.Sprintf("Unable to read current tree: %v", err)}
+	}
+	changes, err := object.DiffTree(before, after)
+	if err != nil {
+		return []string{fmt.Sprintf("Unable to diff trees: %v", err)}
+	}
+	var summary []string
+	for _, change := range changes {
+		action, err := change.Action()
+		if err != nil {
+			continue
+		}
+		switch action {
+		case merkletrie.Insert:
+			summary = append(summary, "added "+change.To.Name)
+		case merkletrie.Delete:
+			summary = append(summary, "deleted "+change.From.Name)
+		default:
+			summary = append(summary, "modified "+change.To.Name)
+		}
+	}
+	return summary
+}
+
+func commitTree(repository *git.Repository, hash plumbing.Hash) (*object.Tree, error) {
+	commit, err := repository.CommitObject(hash)
+	if err != nil {
+		return nil, err
+	}
+	return commit.Tree()
+}
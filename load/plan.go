@@ -0,0 +1,150 @@
+package load
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	gitConfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"source.cyberpi.de/go/teminel/utils"
+)
+
+const (
+	ActionClone    = "clone"
+	ActionTarball  = "tarball"
+	ActionUpdate   = "update"
+	ActionUpToDate = "up to date"
+	ActionReclone  = "reclone"
+)
+
+type Plan struct {
+	Action   string
+	Protocol string
+	URL      string
+	Branch   string
+	From     string
+	To       string
+}
+
+func (source *GitSource) Plan(name string, path string, reference string) (*Plan, error) {
+	workingPath := source.Archive.formatWorkingPath(name, path)
+	if !utils.VerifyPath(workingPath) {
+		return source.planClone(name, reference), nil
+	}
+	repository, err := git.PlainOpen(workingPath)
+	if err != nil {
+		return nil, err
+	}
+	revision, err := readRevision(repository, "", "")
+	if err != nil {
+		return nil, err
+	}
+	if revision.Method == MethodTarball {
+		return &Plan{
+			Action: ActionTarball,
+			URL:    source.Archive.tarballUrl(name, source.Archive.Archive, revision.Branch),
+			Branch: revision.Branch,
+			From:   revision.Commit,
+		}, nil
+	}
+	remote, err := repository.Remote("origin")
+	if err != nil {
+		return nil, err
+	}
+	url := remote.Config().URLs[0]
+	detached := revision.Branch == plumbing.HEAD.String()
+	branch := revision.Branch
+	if len(reference) != 0 || detached {
+		branch = reference
+	}
+	target, err := remoteCommit(url, branch)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to list remote references of %v: %v", url, err)
+	}
+	plan := &Plan{URL: url, Branch: branch, From: revision.Commit, To: target}
+	switch {
+	case strings.HasPrefix(revision.Commit, target):
+		plan.Action = ActionUpToDate
+	case branch != revision.Branch && !detached && !IsCommit(branch):
+		plan.Action = ActionReclone
+	default:
+		plan.Action = ActionUpdate
+	}
+	return plan, nil
+}
+
+func (source *GitSource) planClone(name string, reference string) *Plan {
+	urls := map[string]string{}
+	protocols := source.Protocols
+	if len(source.URL) != 0 {
+		protocols = []string{"url"}
+		urls["url"] = source.URL
+	}
+	for _, protocol := range protocols {
+		url, found := urls[protocol]
+		if !found {
+			url = fmt.Sprintf(selectUrlTemplate(protocol), source.Archive.Host, name)
+		}
+		target, err := remoteCommit(url, reference)
+		if err != nil {
+			fmt.Println("Unable to list remote references with:", protocol, err)
+			continue
+		}
+		return &Plan{Action: ActionClone, Protocol: protocol, URL: url, Branch: reference, To: target}
+	}
+	version := reference
+	if len(version) == 0 && len(source.Archive.Versions) != 0 {
+		version = source.Archive.Versions[0]
+	}
+	return &Plan{
+		Action: ActionTarball,
+		URL:    source.Archive.tarballUrl(name, source.Archive.Archive, version),
+		Branch: version,
+	}
+}
+
+func remoteCommit(url string, reference string) (string, error) {
+	if IsCommit(reference) {
+		return reference, nil
+	}
+	remote := git.NewRemote(memory.NewStorage(), &gitConfig.RemoteConfig{
+		Name: "origin",
+		URLs: []string{url},
+	})
+	references, err := remote.List(&git.ListOptions{PeelingOption: git.AppendPeeled})
+	if err != nil {
+		return "", err
+	}
+	names := []plumbing.ReferenceName{plumbing.HEAD}
+	if len(reference) != 0 {
+		names = []plumbing.ReferenceName{
+			plumbing.NewBranchReferenceName(reference),
+			plumbing.NewTagReferenceName(reference + "^{}"),
+			plumbing.NewTagReferenceName(reference),
+		}
+	}
+	for _, name := range names {
+		if found := findReference(references, name); found != nil {
+			return found.Hash().String(), nil
+		}
+	}
+	if looksLikeCommit(reference) {
+		return reference, nil
+	}
+	return "", fmt.Errorf("Reference not found: %v", reference)
+}
+
+func findReference(references []*plumbing.Reference, name plumbing.ReferenceName) *plumbing.Reference {
+	for _, reference := range references {
+		if reference.Name() != name {
+			continue
+		}
+		if reference.Type() == plumbing.SymbolicReference {
+			return findReference(references, reference.Target())
+		}
+		return reference
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/go-git/go-git/v5"
@@ -23,12 +24,16 @@ type ArchiveSource struct {
 type GitSource struct {
 	Archive   *ArchiveSource
 	Protocols []string
+	URL       string
 }
 
 func (source *GitSource) EnsureBareRepository(name string, path string, cache string) error {
-	err := source.EnsureRepository(name, cache)
+	_, err := source.EnsureRepository(name, cache, "")
 	if err != nil {
-		return err
+		if !utils.VerifyPath(source.Archive.formatWorkingPath(name, cache)) {
+			return err
+		}
+		fmt.Println("Using cached repository:", name, "after error:", err)
 	}
 	barePath := source.Archive.formatWorkingPath(name+".git", path)
 	if utils.VerifyPath(barePath) {
@@ -44,78 +49,194 @@ func (source *GitSource) EnsureBareRepository(name string, path string, cache st
 	return err
 }
 
-func (source *GitSource) EnsureRepository(name string, path string) error {
+func (source *GitSource) EnsureRepository(name string, path string, reference string) (*Revision, error) {
 	workingPath := source.Archive.formatWorkingPath(name, path)
 	fmt.Println("Ensuring repository:", name, "from host:", source.Archive.Host, "on path:", workingPath)
 	if utils.VerifyPath(workingPath) {
 		fmt.Println("Updating repository:", name)
-		source.Archive.updateRepository(name, path)
-	} else {
-		fmt.Println("Cloning repository:", name)
-		options := &git.CloneOptions{
-			SingleBranch: true,
-			Depth:        1,
-			Tags:         git.NoTags,
-		}
-		err := fmt.Errorf("No protocol was set")
-		for _, protocol := range source.Protocols {
-			fmt.Println("Trying to clone repo with:", protocol)
-			options.URL = fmt.Sprintf(selectUrlTemplate(protocol), source.Archive.Host, name)
-			_, err = git.PlainClone(workingPath, false, options)
-			if err == nil {
-				fmt.Println("Repo clone successful using:", protocol)
-				break
-			}
+		return source.updateRepository(name, path, reference)
+	}
+	fmt.Println("Cloning repository:", name)
+	repository, err := source.cloneReference(name, workingPath, reference, 1)
+	if err != nil {
+		fmt.Println("Unable to clone repo using git:", err)
+		return source.Archive.ensureTarballRepository(name, path, reference)
+	}
+	return readRevision(repository, reference, StatusCloned)
+}
+
+func (source *GitSource) EnsureRevision(name string, path string, revision *Revision) (*Revision, error) {
+	if len(revision.Tarball) != 0 {
+		return source.Archive.ensureTarballRevision(name, path, revision)
+	}
+	workingPath := source.Archive.formatWorkingPath(name, path)
+	fmt.Println("Ensuring repository:", name, "at commit:", revision.Commit)
+	if utils.VerifyPath(workingPath) {
+		repository, err := git.PlainOpen(workingPath)
+		if err == nil && checkoutLocked(repository, revision.Branch, revision.Commit) == nil {
+			return readRevision(repository, revision.Branch, StatusCheckedOut)
 		}
-		if err != nil {
-			fmt.Println("Unable to clone repo using git:", err)
-			return source.Archive.ensureTarballRepository(name, path)
+		fmt.Println("Commit not available, cloning repository again:", name)
+		if err := os.RemoveAll(workingPath); err != nil {
+			return nil, err
 		}
 	}
-	return nil
+	repository, err := source.cloneReference(name, workingPath, revision.Branch, 0)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkoutLocked(repository, revision.Branch, revision.Commit); err != nil {
+		return nil, err
+	}
+	return readRevision(repository, revision.Branch, StatusCloned)
 }
 
-func selectUrlTemplate(protocol string) string {
-	switch protocol {
-	case "ssh":
-		return protocol + "://git@%v/%v.git"
+func (source *GitSource) WithHost(host string) *GitSource {
+	archive := *source.Archive
+	archive.Host = host
+	return &GitSource{
+		Archive:   &archive,
+		Protocols: source.Protocols,
+		URL:       source.URL,
 	}
-	return protocol + "://%v/%v.git"
 }
 
-func (source *ArchiveSource) updateRepository(name string, path string) error {
-	workingPath := source.formatWorkingPath(name, path)
+func (source *GitSource) updateRepository(name string, path string, reference string) (*Revision, error) {
+	workingPath := source.Archive.formatWorkingPath(name, path)
 	repository, err := git.PlainOpen(workingPath)
+	if err != nil {
+		return nil, err
+	}
 	remotes, err := repository.Remotes()
 	if err != nil {
-		return nil
+		return nil, err
 	}
-	if len(remotes) != 0 {
-		worktree, err := repository.Worktree()
-		if err != nil {
-			return err
+	if len(remotes) == 0 {
+		return source.Archive.ensureTarballRepository(name, path, reference)
+	}
+	head, err := repository.Head()
+	if err != nil {
+		return nil, err
+	}
+	if !followsReference(head, reference) && checkoutBranch(repository, reference) == nil {
+		if head, err = repository.Head(); err != nil {
+			return nil, err
 		}
-		options := &git.PullOptions{
-			RemoteName: "origin",
+	}
+	if !followsReference(head, reference) {
+		if matchesReference(repository, head, reference) {
+			return readRevision(repository, reference, StatusUpToDate)
+		}
+		fmt.Println("Reference changed, cloning repository again:", name)
+		if err := os.RemoveAll(workingPath); err != nil {
+			return nil, err
 		}
-		err = worktree.Pull(options)
-		if err == git.NoErrAlreadyUpToDate {
+		return source.EnsureRepository(name, path, reference)
+	}
+	worktree, err := repository.Worktree()
+	if err != nil {
+		return nil, err
+	}
+	options := &git.PullOptions{
+		RemoteName:    "origin",
+		ReferenceName: head.Name(),
+		SingleBranch:  true,
+	}
+	err = worktree.Pull(options)
+	if err != nil && err != git.NoErrAlreadyUpToDate {
+		return nil, fmt.Errorf("Unable to update repository %v: %v", name, err)
+	}
+	revision, err := readRevision(repository, reference, StatusUpToDate)
+	if err != nil {
+		return nil, err
+	}
+	if revision.Commit != head.Hash().String() {
+		revision.Status = StatusUpdated
+		revision.Previous = head.Hash().String()
+		revision.Changes = commitSubjects(repository, head.Hash(), plumbing.NewHash(revision.Commit))
+	}
+	return revision, nil
+}
+
+func (source *GitSource) cloneReference(name string, workingPath string, reference string, depth int) (*git.Repository, error) {
+	options := &git.CloneOptions{
+		SingleBranch: true,
+		Depth:        depth,
+		Tags:         git.NoTags,
+	}
+	if len(reference) == 0 {
+		return source.clone(name, workingPath, options)
+	}
+	if IsCommit(reference) {
+		return source.cloneCommit(name, workingPath, reference, options)
+	}
+	options.ReferenceName = plumbing.NewBranchReferenceName(reference)
+	repository, err := source.clone(name, workingPath, options)
+	if err == nil {
+		return repository, nil
+	}
+	fmt.Println("Reference is not a branch, trying tag:", reference)
+	options.ReferenceName = plumbing.NewTagReferenceName(reference)
+	repository, err = source.clone(name, workingPath, options)
+	if err == nil || !looksLikeCommit(reference) {
+		return repository, err
+	}
+	fmt.Println("Reference is not a tag, trying commit:", reference)
+	return source.cloneCommit(name, workingPath, reference, options)
+}
 
-		} else if err != nil {
-			return nil
+func (source *GitSource) cloneCommit(name string, workingPath string, commit string, options *git.CloneOptions) (*git.Repository, error) {
+	options.ReferenceName = ""
+	options.SingleBranch = false
+	options.Depth = 0
+	repository, err := source.clone(name, workingPath, options)
+	if err != nil {
+		return nil, err
+	}
+	return repository, checkoutCommit(repository, commit)
+}
+
+func (source *GitSource) clone(name string, workingPath string, options *git.CloneOptions) (*git.Repository, error) {
+	if len(source.URL) != 0 {
+		fmt.Println("Trying to clone repo from:", source.URL)
+		options.URL = source.URL
+		return git.PlainClone(workingPath, false, options)
+	}
+	err := fmt.Errorf("No protocol was set")
+	for _, protocol := range source.Protocols {
+		fmt.Println("Trying to clone repo with:", protocol)
+		options.URL = fmt.Sprintf(selectUrlTemplate(protocol), source.Archive.Host, name)
+		var repository *git.Repository
+		repository, err = git.PlainClone(workingPath, false, options)
+		if err == nil {
+			fmt.Println("Repo clone successful using:", protocol)
+			return repository, nil
 		}
-	} else {
-		return source.ensureTarballRepository(name, path)
 	}
-	return nil
+	return nil, err
+}
+
+func selectUrlTemplate(protocol string) string {
+	switch protocol {
+	case "ssh":
+		return protocol + "://git@%v/%v.git"
+	}
+	return protocol + "://%v/%v.git"
 }
 
-func (source *ArchiveSource) ensureTarballRepository(name string, path string) error {
-	for _, version := range source.Versions {
+func (source *ArchiveSource) ensureTarballRepository(name string, path string, reference string) (*Revision, error) {
+	versions := source.Versions
+	if len(reference) != 0 {
+		versions = []string{reference}
+	}
+	for _, version := range versions {
 		fmt.Println("Ensuring tarball repository:", name, "version:", version)
-		url := fmt.Sprintf("https://%v/%v/%v/%v.tar.gz", source.Host, name, source.Archive, version)
 		workingPath := source.formatWorkingPath(name, path)
-		err := LoadTarball(url, workingPath)
+		status := StatusUpToDate
+		if !utils.VerifyPath(workingPath) {
+			status = StatusDownloaded
+		}
+		url, digest, err := source.loadTarball(name, version, workingPath, len(reference) != 0)
 		if err != nil {
 			fmt.Println("Tarball failed to load:", err)
 			continue
@@ -123,15 +244,99 @@ func (source *ArchiveSource) ensureTarballRepository(name string, path string) e
 		repository, err := openOrInit(workingPath, version)
 		if err != nil {
 			fmt.Println("Failed to open or init repository:", err)
-			return err
+			return nil, err
 		}
-		err = commit(repository)
+		previous, _ := repository.Head()
+		changed, err := commit(repository)
 		if err != nil {
-			return err
+			return nil, err
+		}
+		revision := &Revision{
+			Branch:  version,
+			Tarball: url,
+			Digest:  digest,
+			Method:  MethodTarball,
+			Status:  status,
+		}
+		if changed && previous != nil {
+			revision.Status = StatusUpdated
+			revision.Previous = previous.Hash().String()
+			revision.Changes = fileChanges(repository, previous.Hash())
 		}
-		break
+		return revision, nil
 	}
-	return nil
+	return nil, fmt.Errorf("No tarball could be loaded for: %v", name)
+}
+
+func (source *ArchiveSource) loadTarball(name string, version string, workingPath string, pinned bool) (string, string, error) {
+	archives := []string{source.Archive}
+	if pinned {
+		archives = source.referenceArchives(version)
+	}
+	err := fmt.Errorf("No archive was set")
+	for _, archive := range archives {
+		url := source.tarballUrl(name, archive, version)
+		var digest string
+		digest, err = LoadTarball(url, workingPath)
+		if err == nil {
+			return url, digest, nil
+		}
+	}
+	return "", "", err
+}
+
+func (source *ArchiveSource) tarballUrl(name string, archive string, version string) string {
+	return fmt.Sprintf("https://%v/%v/%v/%v.tar.gz", source.Host, name, archive, version)
+}
+
+func (source *ArchiveSource) referenceArchives(reference string) []string {
+	if !strings.HasSuffix(source.Archive, "/refs/heads") {
+		return []string{source.Archive}
+	}
+	base := strings.TrimSuffix(source.Archive, "/refs/heads")
+	if IsCommit(reference) {
+		return []string{base}
+	}
+	archives := []string{source.Archive, base + "/refs/tags"}
+	if looksLikeCommit(reference) {
+		archives = append(archives, base)
+	}
+	return archives
+}
+
+func (source *ArchiveSource) ensureTarballRevision(name string, path string, revision *Revision) (*Revision, error) {
+	data, digest, err := FetchTarball(revision.Tarball)
+	if err != nil {
+		return nil, err
+	}
+	if digest != revision.Digest {
+		return nil, fmt.Errorf("Tarball digest mismatch for %v: expected %v, got %v", name, revision.Digest, digest)
+	}
+	workingPath := source.formatWorkingPath(name, path)
+	status := StatusCheckedOut
+	if !utils.VerifyPath(workingPath) {
+		status = StatusDownloaded
+	}
+	if err := clearWorkingTree(workingPath); err != nil {
+		return nil, err
+	}
+	if err := ExtractTarball(data, workingPath); err != nil {
+		return nil, err
+	}
+	repository, err := openOrInit(workingPath, revision.Branch)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := commit(repository); err != nil {
+		return nil, err
+	}
+	return &Revision{
+		Branch:  revision.Branch,
+		Tarball: revision.Tarball,
+		Digest:  digest,
+		Method:  MethodTarball,
+		Status:  status,
+	}, nil
 }
 
 func (source *ArchiveSource) formatWorkingPath(name string, path string) string {
@@ -157,28 +362,29 @@ func openOrInit(path string, branch string) (*git.Repository, error) {
 	}
 }
 
-func commit(repository *git.Repository) error {
+func commit(repository *git.Repository) (bool, error) {
 	worktree, err := repository.Worktree()
 	if err != nil {
-		return err
+		return false, err
 	}
 	status, err := worktree.Status()
 	if err != nil {
-		return err
-	}
-	if !status.IsClean() {
-		options := &git.AddOptions{All: true}
-		worktree.AddWithOptions(options)
-		_, err := worktree.Commit("New commit", &git.CommitOptions{
-			Author: &object.Signature{
-				Name:  "Auto Maton",
-				Email: "teminel@cyberpi.de",
-				When:  time.Now(),
-			},
-		})
-		if err != nil {
-			return err
-		}
+		return false, err
+	}
+	if status.IsClean() {
+		return false, nil
+	}
+	options := &git.AddOptions{All: true}
+	worktree.AddWithOptions(options)
+	_, err = worktree.Commit("New commit", &git.CommitOptions{
+		Author: &object.Signature{
+			Name:  "Auto Maton",
+			Email: "teminel@cyberpi.de",
+			When:  time.Now(),
+		},
+	})
+	if err != nil {
+		return false, err
 	}
-	return nil
+	return true, nil
 }
@@ -0,0 +1,155 @@
+package load
+
+import (
+	"regexp"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+)
+
+const (
+	StatusCloned     = "cloned"
+	StatusDownloaded = "downloaded"
+	StatusUpdated    = "updated"
+	StatusUpToDate   = "up to date"
+	StatusCheckedOut = "checked out"
+
+	MethodTarball = "tarball"
+)
+
+var (
+	commitMatcher      = regexp.MustCompile(`^[0-9a-f]{40}$`)
+	abbreviatedMatcher = regexp.MustCompile(`^[0-9a-f]{7,40}$`)
+)
+
+type Revision struct {
+	Branch   string
+	Commit   string
+	Tarball  string
+	Digest   string
+	Method   string
+	Status   string
+	Previous string
+	Changes  []string
+}
+
+func IsCommit(reference string) bool {
+	return commitMatcher.MatchString(reference)
+}
+
+func looksLikeCommit(reference string) bool {
+	return abbreviatedMatcher.MatchString(reference)
+}
+
+func readRevision(repository *git.Repository, reference string, status string) (*Revision, error) {
+	head, err := repository.Head()
+	if err != nil {
+		return nil, err
+	}
+	branch := reference
+	if len(branch) == 0 {
+		branch = head.Name().Short()
+	}
+	return &Revision{
+		Branch: branch,
+		Commit: head.Hash().String(),
+		Method: readMethod(repository),
+		Status: status,
+	}, nil
+}
+
+func readMethod(repository *git.Repository) string {
+	remote, err := repository.Remote("origin")
+	if err != nil || len(remote.Config().URLs) == 0 {
+		return MethodTarball
+	}
+	endpoint, err := transport.NewEndpoint(remote.Config().URLs[0])
+	if err != nil {
+		return MethodTarball
+	}
+	return endpoint.Protocol
+}
+
+func followsReference(head *plumbing.Reference, reference string) bool {
+	if len(reference) == 0 {
+		return head.Name().IsBranch()
+	}
+	return head.Name() == plumbing.NewBranchReferenceName(reference)
+}
+
+func matchesReference(repository *git.Repository, head *plumbing.Reference, reference string) bool {
+	if len(reference) == 0 {
+		return false
+	}
+	hash, err := repository.ResolveRevision(plumbing.Revision(reference))
+	if err != nil {
+		return false
+	}
+	return *hash == head.Hash()
+}
+
+func checkoutBranch(repository *git.Repository, branch string) error {
+	if len(branch) == 0 {
+		return plumbing.ErrReferenceNotFound
+	}
+	worktree, err := repository.Worktree()
+	if err != nil {
+		return err
+	}
+	options := &git.CheckoutOptions{
+		Branch: plumbing.NewBranchReferenceName(branch),
+		Force:  true,
+	}
+	return worktree.Checkout(options)
+}
+
+func checkoutCommit(repository *git.Repository, commit string) error {
+	hash, err := repository.ResolveRevision(plumbing.Revision(commit))
+	if err != nil {
+		return err
+	}
+	worktree, err := repository.Worktree()
+	if err != nil {
+		return err
+	}
+	options := &git.CheckoutOptions{
+		Hash:  *hash,
+		Force: true,
+	}
+	return worktree.Checkout(options)
+}
+
+func checkoutLocked(repository *git.Repository, branch string, commit string) error {
+	if !hasBranch(repository, branch) {
+		return checkoutCommit(repository, commit)
+	}
+	hash, err := repository.ResolveRevision(plumbing.Revision(commit))
+	if err != nil {
+		return err
+	}
+	reference := plumbing.NewHashReference(plumbing.NewBranchReferenceName(branch), *hash)
+	if err := repository.Storer.SetReference(reference); err != nil {
+		return err
+	}
+	return checkoutBranch(repository, branch)
+}
+
+func hasBranch(repository *git.Repository, branch string) bool {
+	if len(branch) == 0 {
+		return false
+	}
+	if _, err := repository.Reference(plumbing.NewBranchReferenceName(branch), false); err == nil {
+		return true
+	}
+	_, err := repository.Reference(plumbing.NewRemoteReferenceName("origin", branch), false)
+	return err == nil
+}
+
+func Inspect(path string) (*Revision, error) {
+	repository, err := git.PlainOpen(path)
+	if err != nil {
+		return nil, err
+	}
+	return readRevision(repository, "", "")
+}
@@ -2,7 +2,10 @@ package load
 
 import (
 	"archive/tar"
+	"bytes"
 	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"net/http"
@@ -12,17 +15,62 @@ import (
 	"source.cyberpi.de/go/teminel/utils"
 )
 
-func LoadTarball(url string, path string) error {
+func LoadTarball(url string, path string) (string, error) {
+	data, digest, err := FetchTarball(url)
+	if err != nil {
+		return "", err
+	}
+	if err := clearWorkingTree(path); err != nil {
+		return "", err
+	}
+	if err := ExtractTarball(data, path); err != nil {
+		return "", err
+	}
+	return digest, nil
+}
+
+func clearWorkingTree(path string) error {
+	entries, err := os.ReadDir(path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.Name() == ".git" {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(path, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func FetchTarball(url string) ([]byte, string, error) {
 	fmt.Println("Loading tarball at:", url)
 	response, err := http.Get(url)
 	if err != nil {
-		return err
+		return nil, "", err
 	}
+	defer response.Body.Close()
 	if response.StatusCode >= 300 || response.StatusCode < 200 {
-		return fmt.Errorf("Error on tarball retrieval: %v", response.Status)
+		return nil, "", fmt.Errorf("Error on tarball retrieval: %v", response.Status)
 	}
-	defer response.Body.Close()
-	gzipReader, err := gzip.NewReader(response.Body)
+	data, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, Digest(data), nil
+}
+
+func Digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func ExtractTarball(data []byte, path string) error {
+	gzipReader, err := gzip.NewReader(bytes.NewReader(data))
 	if err != nil {
 		return err
 	}
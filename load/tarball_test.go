@@ -0,0 +1,133 @@
+package load
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestLoadTarballUpdate(t *testing.T) {
+	versions := map[string]map[string]string{
+		"/v1.tar.gz": {"plugin.tmux": "echo v1\n", "scripts/old.sh": "echo old\n"},
+		"/v2.tar.gz": {"plugin.tmux": "echo v2\n", "scripts/new.sh": "echo new\n"},
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		files, found := versions[request.URL.Path]
+		if !found {
+			http.NotFound(writer, request)
+			return
+		}
+		writer.Write(testTarball(t, files))
+	}))
+	defer server.Close()
+	path := filepath.Join(t.TempDir(), "plugin")
+	if _, err := LoadTarball(server.URL+"/v1.tar.gz", path); err != nil {
+		t.Fatal(err)
+	}
+	repository, err := openOrInit(path, "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := commit(repository); err != nil {
+		t.Fatal(err)
+	}
+	previous, err := repository.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadTarball(server.URL+"/v2.tar.gz", path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(path, "scripts", "old.sh")); !os.IsNotExist(err) {
+		t.Errorf("file deleted upstream is still present: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(path, ".git")); err != nil {
+		t.Errorf("repository of the working tree was removed: %v", err)
+	}
+	if content, err := os.ReadFile(filepath.Join(path, "plugin.tmux")); err != nil || string(content) != "echo v2\n" {
+		t.Errorf("plugin.tmux = %q, %v, expected the updated content", content, err)
+	}
+	if _, err := commit(repository); err != nil {
+		t.Fatal(err)
+	}
+	changes := fileChanges(repository, previous.Hash())
+	slices.Sort(changes)
+	expected := []string{"added scripts/new.sh", "deleted scripts/old.sh", "modified plugin.tmux"}
+	if !slices.Equal(changes, expected) {
+		t.Errorf("changes %q, expected %q", changes, expected)
+	}
+}
+
+func TestEnsureTarballRevision(t *testing.T) {
+	v1 := testTarball(t, map[string]string{"plugin.tmux": "echo v1\n", "scripts/old.sh": "echo old\n"})
+	v2 := testTarball(t, map[string]string{"plugin.tmux": "echo v2\n", "scripts/new.sh": "echo new\n"})
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		if request.URL.Path == "/v1.tar.gz" {
+			writer.Write(v1)
+		} else {
+			writer.Write(v2)
+		}
+	}))
+	defer server.Close()
+	directory := t.TempDir()
+	if _, err := LoadTarball(server.URL+"/v2.tar.gz", filepath.Join(directory, "plugin")); err != nil {
+		t.Fatal(err)
+	}
+	source := &ArchiveSource{UseBaseName: true}
+	locked := &Revision{Branch: "v1", Tarball: server.URL + "/v1.tar.gz", Digest: Digest(v1)}
+	revision, err := source.ensureTarballRevision("owner/plugin", directory, locked)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if revision.Status != StatusCheckedOut {
+		t.Errorf("status %v, expected %v", revision.Status, StatusCheckedOut)
+	}
+	for name, expected := range map[string]bool{"plugin.tmux": true, "scripts/old.sh": true, "scripts/new.sh": false} {
+		_, err := os.Stat(filepath.Join(directory, "plugin", filepath.FromSlash(name)))
+		if present := err == nil; present != expected {
+			t.Errorf("%v present %v, expected %v", name, present, expected)
+		}
+	}
+	locked.Digest = Digest(v2)
+	if _, err := source.ensureTarballRevision("owner/plugin", directory, locked); err == nil {
+		t.Errorf("tarball with a different digest was accepted")
+	}
+}
+
+func testTarball(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buffer bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buffer)
+	writer := tar.NewWriter(gzipWriter)
+	headers := []*tar.Header{
+		{Name: "plugin-main/", Mode: 0755, Typeflag: tar.TypeDir},
+		{Name: "plugin-main/scripts/", Mode: 0755, Typeflag: tar.TypeDir},
+	}
+	for _, header := range headers {
+		if err := writer.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, content := range files {
+		header := &tar.Header{Name: "plugin-main/" + name, Mode: 0644, Typeflag: tar.TypeReg, Size: int64(len(content))}
+		if err := writer.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := writer.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gzipWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buffer.Bytes()
+}
@@ -24,6 +24,6 @@ func main() {
 			return
 		}
 	}
-	fmt.Println("Starting teminel in tmux plugin manager mode")
+	fmt.Fprintln(os.Stderr, "Starting teminel in tmux plugin manager mode")
 	tmux.Main()
 }